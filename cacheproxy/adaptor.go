@@ -3,7 +3,7 @@ package cacheproxy
 import (
 	"context"
 	"errors"
-	"github.com/bytedance/sonic"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
 	"github.com/redis/go-redis/v9"
 	"time"
 )
@@ -16,17 +16,24 @@ type Cache interface {
 	MSet(ctx context.Context, keys []string, values []StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error
 }
 
+// MultiTTLSetter 可选接口，批量写入时为每个 key 单独指定过期时间
+type MultiTTLSetter interface {
+	MSetWithTTL(ctx context.Context, keys []string, values []StringView, ttls []time.Duration) error
+}
+
 var (
 	ErrInvalidKey     = errors.New("empty key")
 	ErrMismatchedPair = errors.New(" keys and values mismatch")
 )
 
 type RedisCache struct {
-	rdb *redis.Client
+	rdb redis.UniversalClient
+	dep *metrics.DependencyMetric
 }
 
-func NewRedisAdaptor(rdb *redis.Client) *RedisCache {
-	return &RedisCache{rdb: rdb}
+// NewRedisAdaptor 支持单机 *redis.Client、集群 *redis.ClusterClient 以及哨兵 *redis.FailoverClient 等
+func NewRedisAdaptor(rdb redis.UniversalClient) *RedisCache {
+	return &RedisCache{rdb: rdb, dep: metrics.Dependency("redis")}
 }
 
 func (c *RedisCache) Get(ctx context.Context, key string) (StringView, bool, error) {
@@ -37,14 +44,16 @@ func (c *RedisCache) Get(ctx context.Context, key string) (StringView, bool, err
 	if len(key) < 0 {
 		return res, false, ErrInvalidKey
 	}
+	start := time.Now()
 	result, err := c.rdb.Get(ctx, key).Result()
+	c.observe(start, err)
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return StringView{IsNil: true}, false, nil
 		}
 		return res, false, err
 	}
-	err = sonic.UnmarshalString(result, &res)
+	res, err = decodeView(result)
 	if err != nil {
 		return StringView{IsNil: true}, false, err
 	}
@@ -58,7 +67,7 @@ func (c *RedisCache) Set(ctx context.Context, key string, value StringView, expi
 	if len(key) <= 0 {
 		return ErrInvalidKey
 	}
-	valStr, err := sonic.MarshalString(value)
+	valStr, err := encodeView(value)
 	if err != nil {
 		return err
 	}
@@ -66,7 +75,9 @@ func (c *RedisCache) Set(ctx context.Context, key string, value StringView, expi
 	if len(value.Data) == 0 {
 		expired = emptyExpiredTime
 	}
+	start := time.Now()
 	_, err = c.rdb.Set(ctx, key, valStr, expired).Result()
+	c.observe(start, err)
 	return err
 }
 
@@ -77,16 +88,126 @@ func (c *RedisCache) Remove(ctx context.Context, key string) error {
 	if len(key) <= 0 {
 		return ErrInvalidKey
 	}
+	start := time.Now()
 	_, err := c.rdb.Del(ctx, key).Result()
+	c.observe(start, err)
 	return err
 }
 
 func (c *RedisCache) MGet(ctx context.Context, keys []string) ([]StringView, error) {
-	//TODO implement me
-	return nil, nil
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		if len(key) <= 0 {
+			return nil, ErrInvalidKey
+		}
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	start := time.Now()
+	_, err := pipe.Exec(ctx)
+	c.observe(start, err)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	res := make([]StringView, len(keys))
+	for i, cmd := range cmds {
+		result, err := cmd.Result()
+		if err != nil {
+			// 未找到
+			res[i] = StringView{IsNil: true}
+			continue
+		}
+		if res[i], err = decodeView(result); err != nil {
+			res[i] = StringView{IsNil: true}
+		}
+	}
+	return res, nil
 }
 
 func (c *RedisCache) MSet(ctx context.Context, keys []string, values []StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
-	//TODO implement me
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(keys) != len(values) {
+		return ErrMismatchedPair
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := c.rdb.Pipeline()
+	for i, key := range keys {
+		if len(key) <= 0 {
+			return ErrInvalidKey
+		}
+		valStr, err := encodeView(values[i])
+		if err != nil {
+			return err
+		}
+		expired := expiredTime
+		if len(values[i].Data) == 0 {
+			expired = emptyExpiredTime
+		}
+		pipe.Set(ctx, key, valStr, expired)
+	}
+	start := time.Now()
+	_, err := pipe.Exec(ctx)
+	c.observe(start, err)
+	return err
+}
+
+func (c *RedisCache) MSetWithTTL(ctx context.Context, keys []string, values []StringView, ttls []time.Duration) error {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(keys) != len(values) || len(keys) != len(ttls) {
+		return ErrMismatchedPair
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := c.rdb.Pipeline()
+	for i, key := range keys {
+		if len(key) <= 0 {
+			return ErrInvalidKey
+		}
+		valStr, err := encodeView(values[i])
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, key, valStr, ttls[i])
+	}
+	start := time.Now()
+	_, err := pipe.Exec(ctx)
+	c.observe(start, err)
+	return err
+}
+
+// observe 记录一次 Redis 调用，redis.Nil 不计为错误
+func (c *RedisCache) observe(start time.Time, err error) {
+	if errors.Is(err, redis.Nil) {
+		err = nil
+	}
+	c.dep.ObserveCall(time.Since(start), err)
+}
+
+// msetWithTTL 按 key 指定过期时间批量写入，cache 未实现 MultiTTLSetter 时逐个写入
+func msetWithTTL(ctx context.Context, cache Cache, keys []string, values []StringView, ttls []time.Duration) error {
+	if s, ok := cache.(MultiTTLSetter); ok {
+		return s.MSetWithTTL(ctx, keys, values, ttls)
+	}
+	if len(keys) != len(values) || len(keys) != len(ttls) {
+		return ErrMismatchedPair
+	}
+	for i, key := range keys {
+		if err := cache.Set(ctx, key, values[i], ttls[i], ttls[i]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,279 @@
+package cacheproxy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrNotSupported = errors.New("operation not supported by cache")
+
+// ErrKeyNotFound 查询剩余过期时间时 key 不存在
+var ErrKeyNotFound = errors.New("cache key not found")
+
+const defaultScanBatch = 100
+
+// KeyScanner 可选接口，Admin 按前缀遍历与批量删除时使用
+type KeyScanner interface {
+	// Scan 分批遍历以 prefix 开头的 key，fn 返回错误时停止遍历。Redis Cluster 下每个主节点并发遍历，fn 可能被并发调用
+	Scan(ctx context.Context, prefix string, batch int, fn func(keys []string) error) error
+	MRemove(ctx context.Context, keys []string) error
+}
+
+// TTLReader 可选接口，返回 key 的剩余过期时间，小于 0 表示不过期，key 不存在时返回 ErrKeyNotFound
+type TTLReader interface {
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+// EntryInfo 缓存条目及其元信息
+type EntryInfo struct {
+	Key      string
+	CacheKey string
+	Value    StringView
+	// TTL 剩余过期时间，小于 0 表示不过期，缓存不支持查询时为 0
+	TTL time.Duration
+	// InL1 是否存在于进程内一级缓存
+	InL1 bool
+}
+
+// Admin 运维与排查用的缓存操作，key 均为不含 WithKeyPrefix 前缀的 key
+type Admin struct {
+	p *CacheProxy
+}
+
+func (p *CacheProxy) Admin() *Admin {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	return &Admin{p: p}
+}
+
+// Scan 分批遍历以 prefix 开头的 key，基于 SCAN 不会阻塞 Redis。
+// 命名空间下的 key 形如 "ns:v1:key"。Redis Cluster 下各主节点并发遍历，fn 的调用已串行化
+func (a *Admin) Scan(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	scanner, ok := a.p.cache.(KeyScanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	var mu sync.Mutex
+	return scanner.Scan(ctx, a.p.keyPrefix+prefix, defaultScanBatch, func(cacheKeys []string) error {
+		keys := make([]string, len(cacheKeys))
+		for i, cacheKey := range cacheKeys {
+			keys[i] = strings.TrimPrefix(cacheKey, a.p.keyPrefix)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return fn(keys)
+	})
+}
+
+// FlushPrefix 按批删除以 prefix 开头的 key 并广播失效，返回删除的数量。
+// 未配置 WithKeyPrefix 时 prefix 不能为空
+func (a *Admin) FlushPrefix(ctx context.Context, prefix string, batchSize int) (int, error) {
+	if a.p.keyPrefix+prefix == "" {
+		return 0, ErrInvalidKey
+	}
+	scanner, ok := a.p.cache.(KeyScanner)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	if batchSize <= 0 {
+		batchSize = defaultScanBatch
+	}
+	// Redis Cluster 下回调按主节点并发执行
+	var removed atomic.Int64
+	err := scanner.Scan(ctx, a.p.keyPrefix+prefix, batchSize, func(cacheKeys []string) error {
+		if err := scanner.MRemove(ctx, cacheKeys); err != nil {
+			return err
+		}
+		removed.Add(int64(len(cacheKeys)))
+		a.p.publishInvalidate(ctx, "", cacheKeys...)
+		return nil
+	})
+	return int(removed.Load()), err
+}
+
+// Inspect 查看缓存条目的完整元信息，不触发回源
+func (a *Admin) Inspect(ctx context.Context, c CacheContext, key string) (EntryInfo, bool, error) {
+	cacheKey, err := a.p.cacheKey(ctx, c, key)
+	if err != nil {
+		return EntryInfo{}, false, err
+	}
+	info := EntryInfo{Key: key, CacheKey: cacheKey}
+	if a.p.l1 != nil {
+		_, info.InL1 = a.p.l1.Get(cacheKey)
+	}
+	sv, exist, err := a.p.cache.Get(ctx, cacheKey)
+	if err != nil || !exist {
+		return info, false, err
+	}
+	info.Value = sv
+	if r, ok := a.p.cache.(TTLReader); ok {
+		ttl, err := r.TTL(ctx, cacheKey)
+		if errors.Is(err, ErrKeyNotFound) {
+			// 读取后 key 已过期或被删除
+			return info, false, nil
+		}
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			return info, true, err
+		}
+		info.TTL = ttl
+	}
+	return info, true, nil
+}
+
+// escapeMatch 转义 SCAN MATCH 中的通配符
+func escapeMatch(prefix string) string {
+	var b strings.Builder
+	for _, r := range prefix {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (c *RedisCache) Scan(ctx context.Context, prefix string, batch int, fn func(keys []string) error) error {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	match := escapeMatch(prefix) + "*"
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(ctx, cursor, match, int64(batch)).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err = fn(keys); err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	}
+	// 集群模式需要遍历每个主节点
+	if cluster, ok := c.rdb.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	}
+	return scan(ctx, c.rdb)
+}
+
+func (c *RedisCache) MRemove(ctx context.Context, keys []string) error {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	// 集群模式下 key 可能不在同一个槽，逐个删除
+	pipe := c.rdb.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	ttl, err := c.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// PTTL 在 key 不存在时返回 -2，没有过期时间时返回 -1，go-redis 原样转换为 Duration
+	if ttl == -2 {
+		return 0, ErrKeyNotFound
+	}
+	if ttl < 0 {
+		return -1, nil
+	}
+	return ttl, nil
+}
+
+func (c *LocalCache) Scan(ctx context.Context, prefix string, batch int, fn func(keys []string) error) error {
+	now := time.Now()
+	var keys []string
+	for _, shard := range c.shards {
+		shard.mu.RLock()
+		for key, item := range shard.items {
+			if strings.HasPrefix(key, prefix) && !item.expired(now) {
+				keys = append(keys, key)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	for len(keys) > 0 {
+		n := min(batch, len(keys))
+		if err := fn(keys[:n]); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
+
+func (c *LocalCache) MRemove(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := c.Remove(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *LocalCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	shard := c.shard(key)
+	shard.mu.RLock()
+	item, ok := shard.items[key]
+	shard.mu.RUnlock()
+	if !ok || item.expired(time.Now()) {
+		return 0, ErrKeyNotFound
+	}
+	if item.expireAt.IsZero() {
+		return -1, nil
+	}
+	return max(time.Until(item.expireAt), 0), nil
+}
+
+func (c *multiLevelCache) Scan(ctx context.Context, prefix string, batch int, fn func(keys []string) error) error {
+	scanner, ok := c.l2.(KeyScanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	return scanner.Scan(ctx, prefix, batch, fn)
+}
+
+func (c *multiLevelCache) MRemove(ctx context.Context, keys []string) error {
+	scanner, ok := c.l2.(KeyScanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	for _, key := range keys {
+		c.l1.Remove(key)
+	}
+	return scanner.MRemove(ctx, keys)
+}
+
+func (c *multiLevelCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	r, ok := c.l2.(TTLReader)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	return r.TTL(ctx, key)
+}
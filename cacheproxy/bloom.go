@@ -0,0 +1,190 @@
+package cacheproxy
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxBloomBits Redis 位图的最大长度
+const maxBloomBits = 1 << 32
+
+// BloomFilter 记录所有可能存在的业务 key。回源前先查询，判定一定不存在的 key 不再回源，直接按空值缓存。
+// 业务需要在数据创建时调用 Add，回源得到非空值的 key 也会自动加入
+type BloomFilter interface {
+	Add(ctx context.Context, keys ...string) error
+	// MayExist 返回每个 key 是否可能存在，false 表示一定不存在
+	MayExist(ctx context.Context, keys []string) ([]bool, error)
+}
+
+// bloomParams 按预计元素数量与误判率计算位数与哈希函数个数
+func bloomParams(expectedItems uint64, falsePositiveRate float64) (uint64, uint64) {
+	if expectedItems == 0 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	m := math.Ceil(-float64(expectedItems) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	m = min(max(m, 64), maxBloomBits)
+	k := math.Round(m / float64(expectedItems) * math.Ln2)
+	return uint64(m), uint64(max(k, 1))
+}
+
+// bloomLocations 双重哈希计算 key 对应的 k 个位
+func bloomLocations(key string, m uint64, k uint64) []uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	locations := make([]uint64, k)
+	for i := uint64(0); i < k; i++ {
+		locations[i] = (h1 + i*h2) % m
+	}
+	return locations
+}
+
+// LocalBloomFilter 进程内布隆过滤器，进程重启后需要重新加载
+type LocalBloomFilter struct {
+	mu   sync.RWMutex
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// NewLocalBloomFilter expectedItems 为预计元素数量，falsePositiveRate 为误判率，例如 0.01
+func NewLocalBloomFilter(expectedItems uint64, falsePositiveRate float64) *LocalBloomFilter {
+	m, k := bloomParams(expectedItems, falsePositiveRate)
+	return &LocalBloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func (f *LocalBloomFilter) Add(_ context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, key := range keys {
+		for _, loc := range bloomLocations(key, f.m, f.k) {
+			f.bits[loc/64] |= 1 << (loc % 64)
+		}
+	}
+	return nil
+}
+
+func (f *LocalBloomFilter) MayExist(_ context.Context, keys []string) ([]bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	res := make([]bool, len(keys))
+	for i, key := range keys {
+		res[i] = true
+		for _, loc := range bloomLocations(key, f.m, f.k) {
+			if f.bits[loc/64]&(1<<(loc%64)) == 0 {
+				res[i] = false
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// RedisBloomFilter 基于 Redis 位图的布隆过滤器，集群内所有实例共享
+type RedisBloomFilter struct {
+	rdb redis.UniversalClient
+	key string
+	m   uint64
+	k   uint64
+}
+
+// NewRedisBloomFilter key 为存放位图的 Redis key，expectedItems 与 falsePositiveRate 同 NewLocalBloomFilter
+func NewRedisBloomFilter(rdb redis.UniversalClient, key string, expectedItems uint64, falsePositiveRate float64) *RedisBloomFilter {
+	m, k := bloomParams(expectedItems, falsePositiveRate)
+	return &RedisBloomFilter{rdb: rdb, key: key, m: m, k: k}
+}
+
+func (f *RedisBloomFilter) Add(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := f.rdb.Pipeline()
+	for _, key := range keys {
+		for _, loc := range bloomLocations(key, f.m, f.k) {
+			pipe.SetBit(ctx, f.key, int64(loc), 1)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (f *RedisBloomFilter) MayExist(ctx context.Context, keys []string) ([]bool, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	pipe := f.rdb.Pipeline()
+	cmds := make([][]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		for _, loc := range bloomLocations(key, f.m, f.k) {
+			cmds[i] = append(cmds[i], pipe.GetBit(ctx, f.key, int64(loc)))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	res := make([]bool, len(keys))
+	for i := range keys {
+		res[i] = true
+		for _, cmd := range cmds[i] {
+			if cmd.Val() == 0 {
+				res[i] = false
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// WithBloomFilter 回源前查询布隆过滤器，一定不存在的 key 直接按空值缓存，不调用 getter。
+// 查询失败时照常回源
+func WithBloomFilter(filter BloomFilter) Option {
+	return func(o *options) {
+		o.bloom = filter
+	}
+}
+
+// mayExist 过滤出可能存在的 key，未配置布隆过滤器或查询失败时返回全部 key
+func (p *CacheProxy) mayExist(ctx context.Context, keys []string) []string {
+	if p.bloom == nil {
+		return keys
+	}
+	exists, err := p.bloom.MayExist(ctx, keys)
+	if err != nil {
+		logger.Error("cacheProxy bloom filter err:" + err.Error())
+		return keys
+	}
+	res := make([]string, 0, len(keys))
+	for i, key := range keys {
+		if exists[i] {
+			res = append(res, key)
+		}
+	}
+	if rejected := len(keys) - len(res); rejected > 0 {
+		metrics.CacheBloomRejectedMetric(p.name, rejected)
+	}
+	return res
+}
+
+// bloomAdd 将回源得到非空值的 key 加入布隆过滤器
+func (p *CacheProxy) bloomAdd(ctx context.Context, keys ...string) {
+	if p.bloom == nil || len(keys) == 0 {
+		return
+	}
+	if err := p.bloom.Add(ctx, keys...); err != nil {
+		logger.Error("cacheProxy bloom filter add err:" + err.Error())
+	}
+}
@@ -0,0 +1,162 @@
+package cacheproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// circuitBreaker 缓存连续读取失败达到阈值后熔断，冷却期内绕过缓存直接回源
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	// halfOpen 冷却期结束后的试探状态，再次失败立即熔断
+	halfOpen bool
+	// probing 半开状态下已有探测请求在执行，其他请求继续绕过缓存
+	probing bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow 是否允许访问缓存，未开启熔断时总是允许
+func (b *circuitBreaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.halfOpen = true
+	b.probing = true
+	return true
+}
+
+// Release 释放探测名额，不记录成功或失败，用于放行后没有访问缓存的请求
+func (b *circuitBreaker) Release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *circuitBreaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.halfOpen {
+		logger.Info("cacheProxy circuit breaker closed")
+	}
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.halfOpen = false
+	b.probing = false
+}
+
+func (b *circuitBreaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	b.failures++
+	if b.halfOpen || b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		b.failures = 0
+		b.halfOpen = false
+		logger.Warn(fmt.Sprintf("cacheProxy circuit breaker open for %s", b.cooldown))
+	}
+}
+
+// Done 按缓存访问的结果记录，只有连接错误与超时计为失败，返回 err 是否为缓存不可用。
+// 调用方 ctx 已结束时无法判断缓存是否可用，反序列化、解密等错误也不说明连接状态，这两种情况只释放探测名额
+func (b *circuitBreaker) Done(ctx context.Context, err error) bool {
+	switch {
+	case ctx.Err() != nil:
+		b.Release()
+		return false
+	case err == nil:
+		b.Success()
+		return false
+	case isCacheOutage(err):
+		b.Failure()
+		return true
+	default:
+		b.Release()
+		return false
+	}
+}
+
+// isCacheOutage 是否为缓存连接失败或超时
+func isCacheOutage(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, redis.ErrClosed) ||
+		errors.Is(err, redis.ErrPoolTimeout)
+}
+
+// bypassKey 熔断期间使用的 key，命名空间下不查询版本号，避免再访问不可用的 Redis
+func (p *CacheProxy) bypassKey(c CacheContext, key string) string {
+	if c.Namespace == "" {
+		return p.keyPrefix + key
+	}
+	return p.keyPrefix + c.Namespace + ":bypass:" + key
+}
+
+// getBypass 熔断期间绕过缓存直接回源，配置了一级缓存时仍使用一级缓存
+func (p *CacheProxy) getBypass(ctx context.Context, cacheKey string, key string, getter SingleGetter) (string, ResultMeta, error) {
+	if p.l1 != nil {
+		if sv, ok := p.l1.Get(cacheKey); ok {
+			return sv.String(), newResultMeta(CacheStatusFresh, sv), nil
+		}
+	}
+	res, err := p.getResource(ctx, cacheKey, key, getter)
+	if err != nil {
+		return "", ResultMeta{Status: CacheStatusFetched}, err
+	}
+	if p.l1 != nil {
+		p.l1.Set(cacheKey, StringView{Ctime: time.Now(), NeedFastRequery: res.needFastRequery, Data: res.data}, p.l1TTL)
+	}
+	return res.data, ResultMeta{Status: CacheStatusFetched}, nil
+}
+
+// getMultiBypass 熔断期间批量直接回源
+func (p *CacheProxy) getMultiBypass(ctx context.Context, keys []string, getter MissedGetter) (map[string]string, error) {
+	data, _, err := p.getMissedResource(ctx, keys, getter)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(keys))
+	fillResult(res, keys, data)
+	return res, nil
+}
@@ -0,0 +1,56 @@
+package cacheproxy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// hangingCache 读取一直阻塞到调用方 ctx 结束，模拟 Redis 无响应
+type hangingCache struct {
+	*LocalCache
+}
+
+func (c hangingCache) Get(ctx context.Context, key string) (StringView, bool, error) {
+	<-ctx.Done()
+	return StringView{}, false, ctx.Err()
+}
+
+func getWithDeadline(p *CacheProxy) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, _, _ = p.GetHit(ctx, CacheContext{ExpiredTime: time.Minute}, "key", SingleGetterFunc(func(ctx context.Context, key string) (string, bool, error) {
+		return "value", false, nil
+	}))
+}
+
+func TestBreakerIgnoresExpiredCallers(t *testing.T) {
+	p := New(nil, WithCache(hangingCache{NewLocalAdaptor()}), WithCircuitBreaker(2, time.Minute))
+	defer p.Close(context.Background())
+
+	p.breaker.Failure()
+	getWithDeadline(p)
+	p.breaker.Failure()
+	if p.breaker.Allow() {
+		t.Fatal("breaker should open after 2 failures, expired callers must not reset the count")
+	}
+}
+
+func TestBreakerHalfOpenProbeExpired(t *testing.T) {
+	p := New(nil, WithCache(hangingCache{NewLocalAdaptor()}), WithCircuitBreaker(1, time.Millisecond))
+	defer p.Close(context.Background())
+
+	p.breaker.Failure()
+	time.Sleep(2 * time.Millisecond)
+	// 半开状态的探测请求因调用方超时结束
+	getWithDeadline(p)
+	p.breaker.mu.Lock()
+	closed := p.breaker.openUntil.IsZero()
+	p.breaker.mu.Unlock()
+	if closed {
+		t.Fatal("expired probe must not close the breaker")
+	}
+	if !p.breaker.Allow() {
+		t.Fatal("expired probe must release the probe slot")
+	}
+}
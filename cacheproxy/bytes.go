@@ -0,0 +1,153 @@
+package cacheproxy
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"unsafe"
+
+	"github.com/bytedance/sonic"
+)
+
+// binaryMagic 二进制格式条目的首字节，JSON 格式条目以 '{' 开头
+const binaryMagic = 0x00
+
+var ErrInvalidEntry = errors.New("invalid binary cache entry")
+
+// BytesGetter 以 []byte 回源，配合 GetBytes 使用。返回的切片会被复制，之后可以复用
+type BytesGetter interface {
+	GetBytes(ctx context.Context, key string) ([]byte, bool, error)
+}
+
+type BytesGetterFunc func(ctx context.Context, key string) ([]byte, bool, error)
+
+func (f BytesGetterFunc) GetBytes(ctx context.Context, key string) ([]byte, bool, error) {
+	return f(ctx, key)
+}
+
+// bytesGetter 将 BytesGetter 适配为 SingleGetter，回源结果以二进制格式写入
+type bytesGetter struct {
+	getter BytesGetter
+}
+
+func (g bytesGetter) Get(ctx context.Context, key string) (string, bool, error) {
+	data, needFastRequery, err := g.getter.GetBytes(ctx, key)
+	// 复制一份，缓存的值在 singleflight 与一级缓存中共享，不能引用 getter 可能复用的内存
+	return string(data), needFastRequery, err
+}
+
+// BytesView GetBytes 返回的只读值，与缓存（包括一级缓存与其他调用方的结果）共享内存，读取时不复制
+type BytesView struct {
+	s string
+}
+
+func (v BytesView) Len() int {
+	return len(v.s)
+}
+
+// String 返回值本身，不复制
+func (v BytesView) String() string {
+	return v.s
+}
+
+// Bytes 返回值的副本，可以修改
+func (v BytesView) Bytes() []byte {
+	return []byte(v.s)
+}
+
+// WriteTo 将值写入 w，实现 io.WriterTo
+func (v BytesView) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, v.s)
+	return int64(n), err
+}
+
+// UnsafeBytes 不复制，直接返回与缓存共享内存的切片，调用方不能修改，否则会破坏缓存中的值
+func (v BytesView) UnsafeBytes() []byte {
+	return stringToBytes(v.s)
+}
+
+// GetBytes 与 GetHit 相同，值以二进制格式原样存储，不经过 JSON 转义或 base64。
+// 返回只读的 BytesView，需要可修改的切片时使用 Bytes 复制
+func (p *CacheProxy) GetBytes(ctx context.Context, c CacheContext, key string, getter BytesGetter) (BytesView, bool, error) {
+	data, hit, err := p.GetHit(ctx, c, key, bytesGetter{getter: getter})
+	if err != nil {
+		return BytesView{}, false, err
+	}
+	return BytesView{s: data}, hit, nil
+}
+
+// SetBytes 与 Set 相同，值以二进制格式存储，value 会被复制
+func (p *CacheProxy) SetBytes(ctx context.Context, c CacheContext, key string, value []byte) error {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	cacheKey, err := p.cacheKey(ctx, c, key)
+	if err != nil {
+		return err
+	}
+	err = p.setData(ctx, c, cacheKey, resource{data: string(value), binary: true})
+	if err != nil {
+		return err
+	}
+	p.errCache.remove(cacheKey)
+	p.publishInvalidate(ctx, c.Namespace, cacheKey)
+	return nil
+}
+
+// encodeView 序列化缓存条目。二进制条目格式为：binaryMagic + uvarint 头部长度 + 不含 Data 的 JSON 头部 + 原始数据
+func encodeView(sv StringView) (string, error) {
+	if !sv.Binary {
+		return sonic.MarshalString(sv)
+	}
+	data := sv.Data
+	sv.Data = ""
+	header, err := sonic.Marshal(sv)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.Grow(1 + binary.MaxVarintLen64 + len(header) + len(data))
+	b.WriteByte(binaryMagic)
+	var lenBuf [binary.MaxVarintLen64]byte
+	b.Write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(header)))])
+	b.Write(header)
+	b.WriteString(data)
+	return b.String(), nil
+}
+
+// decodeView 反序列化缓存条目，二进制条目的 Data 直接引用 s 的内存
+func decodeView(s string) (StringView, error) {
+	var sv StringView
+	if len(s) == 0 || s[0] != binaryMagic {
+		err := sonic.UnmarshalString(s, &sv)
+		return sv, err
+	}
+	headerLen, n := binary.Uvarint(stringToBytes(s[1:]))
+	if n <= 0 || uint64(len(s)-1-n) < headerLen {
+		return sv, ErrInvalidEntry
+	}
+	start := 1 + n
+	end := start + int(headerLen)
+	if err := sonic.UnmarshalString(s[start:end], &sv); err != nil {
+		return sv, err
+	}
+	sv.Data = s[end:]
+	return sv, nil
+}
+
+func bytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// stringToBytes 返回的切片不能修改
+func stringToBytes(s string) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
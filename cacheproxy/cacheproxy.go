@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+	"github.com/TomWu-Alchemi/project-framework/util"
 	"github.com/redis/go-redis/v9"
 	"golang.org/x/sync/singleflight"
+	"math/rand/v2"
 	"sync"
 	"time"
 )
@@ -24,6 +27,24 @@ func (f SingleGetterFunc) Get(ctx context.Context, key string) (string, bool, er
 	return f(ctx, key)
 }
 
+// SingleGetterV2 回源时可同时返回该 key 的过期时间（例如文档自身的到期时间），
+// 大于 0 时仅对该 key 覆盖 CacheContext.ExpiredTime 与 EmptyExpiredTime，且不叠加抖动
+type SingleGetterV2 interface {
+	SingleGetter
+	GetWithTTL(ctx context.Context, key string) (string, bool, time.Duration, error)
+}
+
+type SingleGetterV2Func func(ctx context.Context, key string) (string, bool, time.Duration, error)
+
+func (f SingleGetterV2Func) Get(ctx context.Context, key string) (string, bool, error) {
+	data, needFastRequery, _, err := f(ctx, key)
+	return data, needFastRequery, err
+}
+
+func (f SingleGetterV2Func) GetWithTTL(ctx context.Context, key string) (string, bool, time.Duration, error) {
+	return f(ctx, key)
+}
+
 type MissedGetterFunc func(ctx context.Context, missedKey []string) (map[string]string, error)
 
 func (f MissedGetterFunc) Get(ctx context.Context, missedKey []string) (map[string]string, error) {
@@ -31,20 +52,82 @@ func (f MissedGetterFunc) Get(ctx context.Context, missedKey []string) (map[stri
 }
 
 const (
+	defaultName        = "default"
 	defaultExpiredTime = 24 * time.Hour
 	defaultRefreshTime = 10 * time.Minute
+	// defaultAsyncTimeout 后台任务默认超时时间，避免 Redis 或数据源变慢时任务无限堆积
+	defaultAsyncTimeout = 5 * time.Second
 )
 
 var (
 	once         sync.Once
 	defaultProxy *CacheProxy
+)
+
+// resource 回源结果
+type resource struct {
+	data            string
+	needFastRequery bool
+	// ttl 大于 0 时覆盖 CacheContext 中的过期时间
+	ttl time.Duration
+	// delta 回源耗时
+	delta time.Duration
+	// binary 以二进制格式存储
+	binary bool
+}
 
-	fastRequeryErr = errors.New("need fast requery")
+// RefreshMode 过期刷新的判定方式
+type RefreshMode int
+
+const (
+	// RefreshModeFixed 超过 RefreshOffset 后刷新
+	RefreshModeFixed RefreshMode = iota
+	// RefreshModeXFetch 按 XFetch 算法在 RefreshOffset 之前概率性提前刷新
+	RefreshModeXFetch
 )
 
+const defaultXFetchBeta = 1.0
+
 type CacheProxy struct {
-	cache    Cache
-	getGroup *singleflight.Group
+	name        string
+	keyPrefix   string
+	cache       Cache
+	getGroup    *singleflight.Group
+	multiGroup  *multiFlight
+	refreshLock *refreshLock
+	l1          *lruCache
+	l1TTL       time.Duration
+	pool        *workerPool
+	breaker     *circuitBreaker
+	versions    versionStore
+
+	maxValueSize   int
+	oversizePolicy OversizePolicy
+	writeBehind    *writeBehindQueue
+	hooks          hooks
+	bloom          BloomFilter
+	limiter        *backSourceLimiter
+	errCache       *errorCache
+	stats          *statsCollector
+
+	// asyncTimeout 后台任务超时时间，baseCtx 在 Close 超时后取消，中断仍在执行的后台任务
+	asyncTimeout time.Duration
+	baseCtx      context.Context
+	cancel       context.CancelFunc
+	closeOnce    sync.Once
+	// refreshStop 与 refreshWG 用于停止 RegisterHotKey 注册的刷新任务，refreshClosed 由 refreshMu 保护
+	refreshStop   chan struct{}
+	refreshWG     sync.WaitGroup
+	refreshMu     sync.Mutex
+	refreshClosed bool
+
+	instanceID        string
+	invalidator       Invalidator
+	invalidateHandler func(keys []string)
+	unsubscribe       func()
+
+	// unregisterPoolMetrics 取消注册 Redis 连接池监控
+	unregisterPoolMetrics func()
 }
 
 type CacheContext struct {
@@ -54,129 +137,590 @@ type CacheContext struct {
 	FastRefreshOffset time.Duration
 	ExpiredTime       time.Duration
 	EmptyExpiredTime  time.Duration
+	// TTLJitter 写入时在过期时间上随机增加 [0, TTLJitter) 的时长，避免同时写入的 key 同时过期
+	TTLJitter time.Duration
+	// TTLJitterRatio 按过期时间的比例增加随机时长，与 TTLJitter 叠加
+	TTLJitterRatio float64
+	// Namespace 非空时缓存 key 中嵌入该命名空间的版本号，BumpVersion 后整个命名空间失效。
+	// 版本号在进程内缓存 WithVersionCacheTTL 时长；New 的 rdb 为 nil 时版本号只保存在进程内
+	Namespace string
+	// RefreshMode 过期刷新的判定方式，仅在 NeedCacheRefresh 时生效
+	RefreshMode RefreshMode
+	// XFetchBeta XFetch 的 beta 系数，<= 0 时取 1
+	XFetchBeta float64
+}
+
+// isExpire 按刷新模式判断缓存是否需要刷新
+func (c CacheContext) isExpire(sv StringView) bool {
+	if c.RefreshMode != RefreshModeXFetch {
+		return sv.IsExpire(c.RefreshOffset, c.FastRefreshOffset)
+	}
+	beta := c.XFetchBeta
+	if beta <= 0 {
+		beta = defaultXFetchBeta
+	}
+	return sv.IsEarlyExpire(c.RefreshOffset, c.FastRefreshOffset, beta)
+}
+
+// jitter 为过期时间增加随机抖动
+func (c CacheContext) jitter(ttl time.Duration) time.Duration {
+	maxJitter := c.TTLJitter + time.Duration(float64(ttl)*c.TTLJitterRatio)
+	if ttl <= 0 || maxJitter <= 0 {
+		return ttl
+	}
+	return ttl + rand.N(maxJitter)
+}
+
+func (c CacheContext) hasJitter() bool {
+	return c.TTLJitter > 0 || c.TTLJitterRatio > 0
 }
 
-func Init(rdb *redis.Client) {
-	defaultProxy = newCacheProxy(rdb)
+// Init 初始化全局默认实例，之后通过 GetInstance 获取
+func Init(rdb redis.UniversalClient, opts ...Option) {
+	defaultProxy = New(rdb, opts...)
 }
 
 func GetInstance() *CacheProxy {
 	return defaultProxy
 }
 
-func newCacheProxy(rdb *redis.Client) *CacheProxy {
-	return &CacheProxy{
-		cache:    NewRedisAdaptor(rdb),
-		getGroup: &singleflight.Group{},
+// New 创建独立的 CacheProxy 实例，各实例拥有自己的 key 前缀、协程池与监控名称，
+// 可用于同时缓存多个 Redis 库或集群。多个实例应通过 WithName 区分监控指标
+func New(rdb redis.UniversalClient, opts ...Option) *CacheProxy {
+	o := options{name: defaultName, queueSize: defaultQueueSize, asyncTimeout: defaultAsyncTimeout, versionCacheTTL: defaultVersionCacheTTL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	p := &CacheProxy{
+		name:              o.name,
+		keyPrefix:         o.keyPrefix,
+		cache:             NewRedisAdaptor(rdb),
+		getGroup:          &singleflight.Group{},
+		multiGroup:        newMultiFlight(),
+		instanceID:        randomID(),
+		invalidator:       o.invalidator,
+		invalidateHandler: o.invalidateHandler,
+		maxValueSize:      o.maxValueSize,
+		oversizePolicy:    o.oversizePolicy,
+		hooks:             o.hooks,
+		bloom:             o.bloom,
+		asyncTimeout:      o.asyncTimeout,
+		stats:             newStatsCollector(),
+	}
+	p.baseCtx, p.cancel = context.WithCancel(context.Background())
+	p.refreshStop = make(chan struct{})
+	if o.cache != nil {
+		p.cache = o.cache
+	}
+	if o.aead != nil {
+		p.cache = newEncryptedCache(p.cache, o.aead)
+	}
+	p.pool = newWorkerPool(p.name, o.workers, o.queueSize, o.dropPolicy)
+	if o.l1Size > 0 {
+		mc := newMultiLevelCache(p.cache, o.l1Size, o.l1TTL)
+		p.cache = mc
+		p.l1 = mc.l1
+		p.l1TTL = o.l1TTL
+	}
+	if o.writeBehindWriter != nil {
+		p.writeBehind = newWriteBehindQueue(p.name, o.writeBehindWriter, o.writeBehindBatch, o.writeBehindInterval)
 	}
+	if o.errorCacheTTL > 0 {
+		p.errCache = newErrorCache(o.errorCacheTTL, o.errorCacheSize)
+	}
+	if o.globalQPS > 0 || o.perKeyQPS > 0 {
+		p.limiter = newBackSourceLimiter(o.globalQPS, o.perKeyQPS)
+	}
+	if o.breakerThreshold > 0 {
+		p.breaker = newCircuitBreaker(o.breakerThreshold, o.breakerCooldown)
+	}
+	if rdb != nil {
+		p.versions = newCachedVersionStore(&redisVersionStore{rdb: rdb, prefix: p.keyPrefix}, o.versionCacheTTL)
+		p.unregisterPoolMetrics = metrics.RegisterRedisPoolCollector(p.name, rdb)
+	} else {
+		// 没有 Redis 时版本号只在进程内，BumpVersion 不影响其他实例
+		p.versions = &localVersionStore{versions: make(map[string]int64)}
+	}
+	if o.lockTTL > 0 && rdb != nil {
+		p.refreshLock = &refreshLock{rdb: rdb, ttl: o.lockTTL, wait: o.lockWait}
+	}
+	if p.invalidator != nil {
+		unsubscribe, err := p.invalidator.Subscribe(p.onInvalidate)
+		if err != nil {
+			logger.Error("cacheProxy subscribe invalidate err:" + err.Error())
+		}
+		p.unsubscribe = unsubscribe
+	}
+	return p
 }
 
 // GetHit string：存储值，bool：是否在缓存中找到，error：错误
 func (p *CacheProxy) GetHit(ctx context.Context, c CacheContext, key string, getter SingleGetter) (string, bool, error) {
+	data, meta, err := p.GetHitWithMeta(ctx, c, key, getter)
+	if err != nil {
+		return "", false, err
+	}
+	return data, meta.Status != CacheStatusFetched, nil
+}
+
+// GetHitWithMeta 与 GetHit 相同，额外返回结果是新鲜的、过期待刷新的还是回源获取的，以及缓存条目的存在时间
+func (p *CacheProxy) GetHitWithMeta(ctx context.Context, c CacheContext, key string, getter SingleGetter) (string, ResultMeta, error) {
 	if p == nil {
 		panic("empty cacheProxy")
 	}
+	fetched := ResultMeta{Status: CacheStatusFetched}
 	if len(key) == 0 {
-		return "", false, nil
+		return "", fetched, nil
+	}
+	// 熔断期间不查询命名空间版本号，直接绕过缓存
+	if !p.breaker.Allow() {
+		return p.getBypass(ctx, p.bypassKey(c, key), key, getter)
+	}
+	cacheKey, err := p.cacheKey(ctx, c, key)
+	if err != nil {
+		if p.breaker != nil && p.breaker.Done(ctx, err) {
+			return p.getBypass(ctx, p.bypassKey(c, key), key, getter)
+		}
+		return "", fetched, err
 	}
 	// 强制刷新，不查询缓存，只回源并对缓存赋值
 	if c.NeedForceRefresh {
-		data, needFastRequery, err := p.getResource(ctx, key, getter)
+		p.recordRefresh(metrics.CacheRefreshForce)
+		p.hooks.refresh(ctx, true, key)
+		res, err := p.getResource(ctx, cacheKey, key, getter)
+		if errors.Is(err, ErrThrottled) {
+			// 强制刷新被限流，按普通读取返回缓存中的值
+			p.breaker.Release()
+			c.NeedForceRefresh = false
+			return p.GetHitWithMeta(ctx, c, key, getter)
+		}
 		if err != nil {
-			return "", false, err
+			p.breaker.Release()
+			return "", fetched, err
 		}
-		err = p.setData(context.Background(), c, key, data, needFastRequery)
+		err = p.setData(context.Background(), c, cacheKey, res)
+		p.breaker.Done(ctx, err)
 		if err != nil {
-			return "", false, err
+			return "", fetched, err
 		}
-		return data, false, nil
+		return res.data, fetched, nil
 	}
 
-	sv, exist, err := p.cache.Get(ctx, key)
+	sv, exist, err := p.cache.Get(ctx, cacheKey)
 	if err != nil {
-		return "", false, err
+		if p.breaker != nil && p.breaker.Done(ctx, err) {
+			return p.getBypass(ctx, cacheKey, key, getter)
+		}
+		return "", fetched, err
 	}
+	p.breaker.Success()
 	if !exist {
+		p.recordMiss(key)
+		p.hooks.miss(ctx, key)
+		unlock, locked := p.tryRefreshLock(ctx, cacheKey)
+		if !locked {
+			// 其他实例正在回源，短暂等待其写入缓存
+			if sv, ok := p.waitRefreshed(ctx, cacheKey); ok {
+				return sv.String(), newResultMeta(CacheStatusFresh, sv), nil
+			}
+		}
 		// 缓存未命中，回源并写入
-		data, needFastRequery, err := p.getResource(ctx, key, getter)
+		res, err := p.getResource(ctx, cacheKey, key, getter)
 		if err != nil {
-			return "", false, err
+			unlock()
+			return "", fetched, err
 		}
 		// 异步写入
-		go func() {
-			setErr := p.setData(context.Background(), c, key, data, needFastRequery)
+		if !p.async(func(asyncCtx context.Context) {
+			defer unlock()
+			setErr := p.setData(asyncCtx, c, cacheKey, res)
 			if setErr != nil {
 				logger.Error("cacheProxy setErr:" + setErr.Error())
 			}
-		}()
-		return data, false, nil
+		}) {
+			unlock()
+		}
+		return res.data, fetched, nil
 	}
 
+	p.recordHit(1)
+	p.hooks.hit(ctx, key)
 	if c.NeedCacheRefresh {
-		if !sv.IsExpire(c.RefreshOffset, c.FastRefreshOffset) {
-			return sv.String(), true, nil
+		if !c.isExpire(sv) {
+			return sv.String(), newResultMeta(CacheStatusFresh, sv), nil
 		}
-		// 过期刷新
-		go func() {
-			newCtx := context.Background()
-			data, needFastRequery, err2 := p.getResource(newCtx, key, getter)
+		// 过期刷新，其他实例正在刷新时继续使用旧值
+		unlock, locked := p.tryRefreshLock(ctx, cacheKey)
+		if !locked {
+			return sv.String(), newResultMeta(CacheStatusStale, sv), nil
+		}
+		p.recordRefresh(metrics.CacheRefreshBackground)
+		p.hooks.refresh(ctx, false, key)
+		if !p.async(func(asyncCtx context.Context) {
+			defer unlock()
+			res, err2 := p.getResource(asyncCtx, cacheKey, key, getter)
+			if isQuietErr(err2) {
+				return
+			}
 			if err2 != nil {
 				logger.Error("cacheProxy refresh getResource err:" + err2.Error())
+				return
 			}
-			err2 = p.setData(newCtx, c, key, data, needFastRequery)
+			err2 = p.setData(asyncCtx, c, cacheKey, res)
 			if err2 != nil {
 				logger.Error("cacheProxy refresh setData err:" + err2.Error())
 			}
-		}()
+		}) {
+			unlock()
+		}
+		return sv.String(), newResultMeta(CacheStatusStale, sv), nil
+	}
+
+	return sv.String(), newResultMeta(CacheStatusFresh, sv), nil
+}
+
+// GetWithMeta 与 GetHitWithMeta 相同，命中缓存时额外查询条目的剩余过期时间，多一次 Redis 请求，适用于排查与调试接口
+func (p *CacheProxy) GetWithMeta(ctx context.Context, c CacheContext, key string, getter SingleGetter) (string, ResultMeta, error) {
+	data, meta, err := p.GetHitWithMeta(ctx, c, key, getter)
+	if err != nil || meta.Status == CacheStatusFetched {
+		return data, meta, err
+	}
+	r, ok := p.cache.(TTLReader)
+	if !ok {
+		return data, meta, nil
+	}
+	cacheKey, err := p.cacheKey(ctx, c, key)
+	if err != nil {
+		return data, meta, nil
+	}
+	ttl, err := r.TTL(ctx, cacheKey)
+	if err != nil {
+		if !errors.Is(err, ErrNotSupported) && !errors.Is(err, ErrKeyNotFound) {
+			logger.Error("cacheProxy get ttl err:" + err.Error())
+		}
+		return data, meta, nil
+	}
+	meta.TTL = ttl
+	return data, meta, nil
+}
+
+// GetMultiHit 批量获取，返回缓存或回源得到的非空值，不存在的 key 不在结果中。
+// 仅对未命中的 key 调用一次 getter 批量回源，并异步写回缓存
+func (p *CacheProxy) GetMultiHit(ctx context.Context, c CacheContext, keys []string, getter MissedGetter) (map[string]string, error) {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	keys = validKeys(keys)
+	res := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return res, nil
+	}
+	// 熔断期间不查询命名空间版本号，直接绕过缓存
+	if !p.breaker.Allow() {
+		return p.getMultiBypass(ctx, keys, getter)
+	}
+	cacheKeys, err := p.cacheKeys(ctx, c, keys)
+	if err != nil {
+		if p.breaker != nil && p.breaker.Done(ctx, err) {
+			return p.getMultiBypass(ctx, keys, getter)
+		}
+		return nil, err
+	}
+	// 强制刷新，不查询缓存，只回源并对缓存赋值
+	if c.NeedForceRefresh {
+		p.recordRefresh(metrics.CacheRefreshForce)
+		p.hooks.refresh(ctx, true, keys...)
+		data, delta, err := p.getMultiResource(ctx, keys, cacheKeys, getter)
+		if err != nil {
+			p.breaker.Release()
+			return nil, err
+		}
+		err = p.setMultiData(context.Background(), c, keys, cacheKeys, data, delta)
+		p.breaker.Done(ctx, err)
+		if err != nil {
+			return nil, err
+		}
+		fillResult(res, keys, data)
+		return res, nil
+	}
+
+	svs, err := p.cache.MGet(ctx, cacheKeys)
+	if err != nil {
+		if p.breaker != nil && p.breaker.Done(ctx, err) {
+			return p.getMultiBypass(ctx, keys, getter)
+		}
+		return nil, err
+	}
+	p.breaker.Success()
+	var missed, missedCacheKeys, expired, expiredCacheKeys []string
+	for i, sv := range svs {
+		if sv.IsNil {
+			missed = append(missed, keys[i])
+			missedCacheKeys = append(missedCacheKeys, cacheKeys[i])
+			continue
+		}
+		p.hooks.hit(ctx, keys[i])
+		if c.NeedCacheRefresh && c.isExpire(sv) {
+			expired = append(expired, keys[i])
+			expiredCacheKeys = append(expiredCacheKeys, cacheKeys[i])
+		}
+		if sv.Len() > 0 {
+			res[keys[i]] = sv.String()
+		}
+	}
+
+	p.recordHit(len(keys) - len(missed))
+	if len(missed) > 0 {
+		p.recordMiss(missed...)
+		p.hooks.miss(ctx, missed...)
+		// 缓存未命中，批量回源并异步写入
+		data, delta, err := p.getMultiResource(ctx, missed, missedCacheKeys, getter)
+		if err != nil {
+			return nil, err
+		}
+		fillResult(res, missed, data)
+		p.async(func(asyncCtx context.Context) {
+			setErr := p.setMultiData(asyncCtx, c, missed, missedCacheKeys, data, delta)
+			if setErr != nil {
+				logger.Error("cacheProxy multi setErr:" + setErr.Error())
+			}
+		})
+	}
+
+	if len(expired) > 0 {
+		// 过期刷新
+		p.recordRefresh(metrics.CacheRefreshBackground)
+		p.hooks.refresh(ctx, false, expired...)
+		p.async(func(asyncCtx context.Context) {
+			data, delta, err2 := p.getMultiResource(asyncCtx, expired, expiredCacheKeys, getter)
+			if isQuietErr(err2) {
+				return
+			}
+			if err2 != nil {
+				logger.Error("cacheProxy multi refresh getResource err:" + err2.Error())
+				return
+			}
+			err2 = p.setMultiData(asyncCtx, c, expired, expiredCacheKeys, data, delta)
+			if err2 != nil {
+				logger.Error("cacheProxy multi refresh setData err:" + err2.Error())
+			}
+		})
 	}
 
-	return sv.String(), true, nil
+	return res, nil
 }
 
 func (p *CacheProxy) Set(ctx context.Context, c CacheContext, key string, value string) error {
 	if p == nil {
 		panic("empty cacheProxy")
 	}
-	return p.setData(ctx, c, key, value, false)
+	cacheKey, err := p.cacheKey(ctx, c, key)
+	if err != nil {
+		return err
+	}
+	err = p.setData(ctx, c, cacheKey, resource{data: value})
+	if err != nil {
+		return err
+	}
+	p.errCache.remove(cacheKey)
+	p.publishInvalidate(ctx, c.Namespace, cacheKey)
+	return nil
 }
 
 func (p *CacheProxy) Remove(ctx context.Context, c CacheContext, key string) error {
 	if p == nil {
 		panic("empty cacheProxy")
 	}
-	return p.cache.Remove(ctx, key)
+	cacheKey, err := p.cacheKey(ctx, c, key)
+	if err != nil {
+		return err
+	}
+	err = p.cache.Remove(ctx, cacheKey)
+	if err != nil {
+		return err
+	}
+	p.errCache.remove(cacheKey)
+	p.publishInvalidate(ctx, c.Namespace, cacheKey)
+	return nil
 }
 
-func (p *CacheProxy) getResource(ctx context.Context, key string, getter SingleGetter) (string, bool, error) {
-	val, err, _ := p.getGroup.Do(key, func() (interface{}, error) {
+// getResource 以 cacheKey 合并并发回源，getter 使用业务 key
+func (p *CacheProxy) getResource(ctx context.Context, cacheKey string, key string, getter SingleGetter) (resource, error) {
+	if err := p.errCache.get(cacheKey); err != nil {
+		return resource{}, err
+	}
+	val, err, _ := p.getGroup.Do(cacheKey, func() (interface{}, error) {
+		var res resource
 		var getErr error
-		data, needFastRequery, getErr := getter.Get(ctx, key)
+		// 布隆过滤器判定一定不存在，直接返回空值
+		if len(p.mayExist(ctx, []string{key})) == 0 {
+			return res, nil
+		}
+		if !p.allowBackSource(key) {
+			return nil, ErrThrottled
+		}
+		start := time.Now()
+		if g, ok := getter.(SingleGetterV2); ok {
+			res.data, res.needFastRequery, res.ttl, getErr = g.GetWithTTL(ctx, key)
+		} else {
+			res.data, res.needFastRequery, getErr = getter.Get(ctx, key)
+		}
+		res.delta = time.Since(start)
+		_, res.binary = getter.(bytesGetter)
+		p.recordBackSource(res.delta, getErr)
 		if getErr != nil {
+			p.hooks.backSourceError(ctx, []string{key}, getErr)
+			p.errCache.set(getErr, cacheKey)
 			return nil, getErr
 		}
-		if needFastRequery {
-			return data, fastRequeryErr
+		if len(res.data) > 0 {
+			p.bloomAdd(ctx, key)
 		}
-		return data, nil
+		return res, nil
 	})
-	res := val.(string)
 	if err != nil {
-		if errors.Is(err, fastRequeryErr) {
-			// 需要快速回源
-			return res, true, nil
-		} else {
-			return "", false, err
-		}
+		return resource{}, err
 	}
-	return res, false, nil
+	return val.(resource), nil
 }
 
-func (p *CacheProxy) setData(ctx context.Context, c CacheContext, key string, data string, needFastRequery bool) error {
+func (p *CacheProxy) setData(ctx context.Context, c CacheContext, cacheKey string, res resource) error {
 	sv := StringView{
 		Ctime:           time.Now(),
-		NeedFastRequery: needFastRequery,
+		NeedFastRequery: res.needFastRequery,
 		IsNil:           false,
-		Data:            data,
+		Data:            res.data,
+		Delta:           res.delta,
+		Binary:          res.binary,
+	}
+	p.recordSize(cacheKey, sv.Len())
+	if !p.checkSize(&sv) {
+		return p.cache.Remove(ctx, cacheKey)
+	}
+	if res.ttl > 0 {
+		return p.cache.Set(ctx, cacheKey, sv, res.ttl, res.ttl)
+	}
+	return p.cache.Set(ctx, cacheKey, sv, c.jitter(c.ExpiredTime), c.jitter(c.EmptyExpiredTime))
+}
+
+// getMultiResource 以 cacheKeys 合并并发的批量回源，每个 key 只回源一次
+func (p *CacheProxy) getMultiResource(ctx context.Context, keys []string, cacheKeys []string, getter MissedGetter) (map[string]string, time.Duration, error) {
+	if err := p.errCache.get(cacheKeys...); err != nil {
+		return nil, 0, err
+	}
+	data, delta, err := p.multiGroup.do(ctx, keys, cacheKeys, func(keys []string) (map[string]string, time.Duration, error) {
+		return p.getMissedResource(ctx, keys, getter)
+	})
+	if err != nil {
+		p.errCache.set(err, cacheKeys...)
+	}
+	return data, delta, err
+}
+
+// getMissedResource 批量回源，同时返回回源耗时
+func (p *CacheProxy) getMissedResource(ctx context.Context, keys []string, getter MissedGetter) (map[string]string, time.Duration, error) {
+	// 布隆过滤器判定一定不存在的 key 不回源
+	keys = p.mayExist(ctx, keys)
+	if len(keys) == 0 {
+		return map[string]string{}, 0, nil
+	}
+	if !p.allowBackSource(keys...) {
+		return nil, 0, ErrThrottled
+	}
+	start := time.Now()
+	data, err := getter.Get(ctx, keys)
+	delta := time.Since(start)
+	p.recordBackSource(delta, err)
+	if err != nil {
+		p.hooks.backSourceError(ctx, keys, err)
+		return data, delta, err
+	}
+	if p.bloom != nil {
+		existing := make([]string, 0, len(data))
+		for key, v := range data {
+			if len(v) > 0 {
+				existing = append(existing, key)
+			}
+		}
+		p.bloomAdd(ctx, existing...)
+	}
+	return data, delta, nil
+}
+
+// setMultiData 批量写入，getter 未返回的 key 以空值写入，防止缓存穿透。keys 为业务 key，cacheKeys 为对应的缓存 key
+func (p *CacheProxy) setMultiData(ctx context.Context, c CacheContext, keys []string, cacheKeys []string, data map[string]string, delta time.Duration) error {
+	now := time.Now()
+	values := make([]StringView, len(keys))
+	for i, key := range keys {
+		values[i] = StringView{
+			Ctime: now,
+			Data:  data[key],
+			Delta: delta,
+		}
+		p.recordSize(cacheKeys[i], values[i].Len())
+	}
+	if !c.hasJitter() && p.maxValueSize <= 0 {
+		return p.cache.MSet(ctx, cacheKeys, values, c.ExpiredTime, c.EmptyExpiredTime)
+	}
+	// 每个 key 单独抖动，并按大小限制过滤
+	ttls := make([]time.Duration, len(keys))
+	for i, value := range values {
+		if len(value.Data) == 0 {
+			ttls[i] = c.jitter(c.EmptyExpiredTime)
+		} else {
+			ttls[i] = c.jitter(c.ExpiredTime)
+		}
+	}
+	return p.setMultiChecked(ctx, cacheKeys, values, ttls)
+}
+
+// cacheKey 返回实际写入缓存的 key：前缀 + [命名空间:v版本:] + 业务 key。
+// CacheProxy 对外方法均使用业务 key，访问缓存时再转换
+func (p *CacheProxy) cacheKey(ctx context.Context, c CacheContext, key string) (string, error) {
+	if c.Namespace == "" {
+		return p.keyPrefix + key, nil
+	}
+	version, err := p.versions.Get(ctx, c.Namespace)
+	if err != nil {
+		return "", err
+	}
+	return p.keyPrefix + versionedKey(c.Namespace, version, key), nil
+}
+
+func (p *CacheProxy) cacheKeys(ctx context.Context, c CacheContext, keys []string) ([]string, error) {
+	if p.keyPrefix == "" && c.Namespace == "" {
+		return keys, nil
+	}
+	prefix := p.keyPrefix
+	if c.Namespace != "" {
+		version, err := p.versions.Get(ctx, c.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		prefix += versionedKey(c.Namespace, version, "")
+	}
+	res := make([]string, len(keys))
+	for i, key := range keys {
+		res[i] = prefix + key
+	}
+	return res, nil
+}
+
+// validKeys 去除空 key 与重复 key
+func validKeys(keys []string) []string {
+	res := make([]string, 0, len(keys))
+	for _, key := range util.SliceRemoveDuplicates(keys) {
+		if len(key) > 0 {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+func fillResult(res map[string]string, keys []string, data map[string]string) {
+	for _, key := range keys {
+		if v := data[key]; len(v) > 0 {
+			res[key] = v
+		}
 	}
-	return p.cache.Set(ctx, key, sv, c.ExpiredTime, c.EmptyExpiredTime)
 }
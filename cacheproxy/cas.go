@@ -0,0 +1,158 @@
+package cacheproxy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CompareAndSetter 可选接口，缓存条目的写入时间等于 expectedCtime 时才写入，expectedCtime 为零值表示 key 不存在时才写入
+type CompareAndSetter interface {
+	CompareAndSet(ctx context.Context, key string, expectedCtime time.Time, value StringView, ttl time.Duration) (bool, error)
+}
+
+// casScript 当前值与读取时一致才写入，ARGV[1] 为空表示 key 不存在时才写入
+var casScript = redis.NewScript(`
+local cur = redis.call("GET", KEYS[1])
+if ARGV[1] == "" then
+	if cur then
+		return 0
+	end
+elseif cur ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// CompareAndSet 缓存条目的写入时间（GetWithMeta 返回的 Ctime）仍为 expectedCtime 时才写入 newValue，
+// 多个写入方并发更新派生数据时避免相互覆盖。expectedCtime 为零值表示 key 不存在时才写入。
+// 返回是否写入成功，值超过 WithMaxValueSize 且策略为 OversizePassThrough 时返回 false
+func (p *CacheProxy) CompareAndSet(ctx context.Context, c CacheContext, key string, expectedCtime time.Time, newValue string) (bool, error) {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	cas, ok := p.cache.(CompareAndSetter)
+	if !ok {
+		return false, ErrNotSupported
+	}
+	cacheKey, err := p.cacheKey(ctx, c, key)
+	if err != nil {
+		return false, err
+	}
+	sv := StringView{Ctime: time.Now(), Data: newValue}
+	if !p.checkSize(&sv) {
+		return false, nil
+	}
+	ttl := c.jitter(c.ExpiredTime)
+	if len(newValue) == 0 {
+		ttl = c.jitter(c.EmptyExpiredTime)
+	}
+	swapped, err := cas.CompareAndSet(ctx, cacheKey, expectedCtime, sv, ttl)
+	if err != nil || !swapped {
+		return false, err
+	}
+	p.errCache.remove(cacheKey)
+	p.publishInvalidate(ctx, c.Namespace, cacheKey)
+	return true, nil
+}
+
+func (c *RedisCache) CompareAndSet(ctx context.Context, key string, expectedCtime time.Time, value StringView, ttl time.Duration) (bool, error) {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(key) <= 0 {
+		return false, ErrInvalidKey
+	}
+	// 读取当前值比较写入时间，脚本中再确认值未被修改
+	expected := ""
+	if !expectedCtime.IsZero() {
+		cur, err := c.rdb.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		sv, err := decodeView(cur)
+		if err != nil {
+			return false, err
+		}
+		if !sv.Ctime.Equal(expectedCtime) {
+			return false, nil
+		}
+		expected = cur
+	}
+	valStr, err := encodeView(value)
+	if err != nil {
+		return false, err
+	}
+	n, err := casScript.Run(ctx, c.rdb, []string{key}, expected, valStr, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (c *LocalCache) CompareAndSet(ctx context.Context, key string, expectedCtime time.Time, value StringView, ttl time.Duration) (bool, error) {
+	if len(key) <= 0 {
+		return false, ErrInvalidKey
+	}
+	shard := c.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	item, ok := shard.items[key]
+	if ok && item.expired(time.Now()) {
+		ok = false
+	}
+	if expectedCtime.IsZero() {
+		if ok {
+			return false, nil
+		}
+	} else if !ok || !item.value.Ctime.Equal(expectedCtime) {
+		return false, nil
+	}
+	item = localItem{value: value}
+	if ttl > 0 {
+		item.expireAt = time.Now().Add(ttl)
+	}
+	shard.items[key] = item
+	return true, nil
+}
+
+func (c *multiLevelCache) CompareAndSet(ctx context.Context, key string, expectedCtime time.Time, value StringView, ttl time.Duration) (bool, error) {
+	cas, ok := c.l2.(CompareAndSetter)
+	if !ok {
+		return false, ErrNotSupported
+	}
+	swapped, err := cas.CompareAndSet(ctx, key, expectedCtime, value, ttl)
+	if err != nil || !swapped {
+		// 一级缓存可能已过时
+		c.l1.Remove(key)
+		return false, err
+	}
+	c.l1.Set(key, value, c.ttl(value, ttl, ttl))
+	return true, nil
+}
+
+func (c *encryptedCache) CompareAndSet(ctx context.Context, key string, expectedCtime time.Time, value StringView, ttl time.Duration) (bool, error) {
+	cas, ok := c.inner.(CompareAndSetter)
+	if !ok {
+		return false, ErrNotSupported
+	}
+	sv, err := c.encrypt(key, value)
+	if err != nil {
+		return false, err
+	}
+	return cas.CompareAndSet(ctx, key, expectedCtime, sv, ttl)
+}
+
+func (c *ShardedCache) CompareAndSet(ctx context.Context, key string, expectedCtime time.Time, value StringView, ttl time.Duration) (bool, error) {
+	return c.shard(key).CompareAndSet(ctx, key, expectedCtime, value, ttl)
+}
@@ -0,0 +1,178 @@
+package cacheproxy
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+var ErrDecrypt = errors.New("cache value decrypt failed")
+
+// NewAESGCM 使用 16、24 或 32 字节的密钥创建 AES-GCM，配合 WithEncryption 使用
+func NewAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// WithEncryption 写入 Redis 前加密 StringView.Data，读取时解密，进程内一级缓存保存明文。
+// 密文与缓存 key 绑定，无法被挪用到其他 key；开启前写入的明文条目仍可正常读取。
+// WithMaxValueSize 按明文计算，存储的密文附加了 nonce 与认证标签，编码后比明文更大
+func WithEncryption(aead cipher.AEAD) Option {
+	return func(o *options) {
+		o.aead = aead
+	}
+}
+
+// encryptedCache 对 Data 加密的 Cache 包装，空值不加密以保持空值缓存的过期策略
+type encryptedCache struct {
+	inner Cache
+	aead  cipher.AEAD
+}
+
+func newEncryptedCache(inner Cache, aead cipher.AEAD) *encryptedCache {
+	return &encryptedCache{inner: inner, aead: aead}
+}
+
+func (c *encryptedCache) encrypt(key string, sv StringView) (StringView, error) {
+	if len(sv.Data) == 0 {
+		return sv, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(sv.Data)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return sv, err
+	}
+	sealed := c.aead.Seal(nonce, nonce, stringToBytes(sv.Data), []byte(key))
+	// 二进制条目直接存储密文
+	if sv.Binary {
+		sv.Data = bytesToString(sealed)
+	} else {
+		sv.Data = base64.StdEncoding.EncodeToString(sealed)
+	}
+	sv.Encrypted = true
+	return sv, nil
+}
+
+func (c *encryptedCache) decrypt(key string, sv StringView) (StringView, error) {
+	if !sv.Encrypted {
+		return sv, nil
+	}
+	sealed := stringToBytes(sv.Data)
+	if !sv.Binary {
+		var err error
+		if sealed, err = base64.StdEncoding.DecodeString(sv.Data); err != nil {
+			return StringView{IsNil: true}, ErrDecrypt
+		}
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return StringView{IsNil: true}, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return StringView{IsNil: true}, ErrDecrypt
+	}
+	sv.Data = string(plain)
+	sv.Encrypted = false
+	return sv, nil
+}
+
+func (c *encryptedCache) encryptAll(keys []string, values []StringView) ([]StringView, error) {
+	if len(keys) != len(values) {
+		return nil, ErrMismatchedPair
+	}
+	res := make([]StringView, len(values))
+	for i, value := range values {
+		sv, err := c.encrypt(keys[i], value)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = sv
+	}
+	return res, nil
+}
+
+func (c *encryptedCache) Get(ctx context.Context, key string) (StringView, bool, error) {
+	sv, exist, err := c.inner.Get(ctx, key)
+	if err != nil || !exist {
+		return sv, exist, err
+	}
+	sv, err = c.decrypt(key, sv)
+	if err != nil {
+		return sv, false, err
+	}
+	return sv, true, nil
+}
+
+func (c *encryptedCache) Set(ctx context.Context, key string, value StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
+	sv, err := c.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return c.inner.Set(ctx, key, sv, expiredTime, emptyExpiredTime)
+}
+
+func (c *encryptedCache) Remove(ctx context.Context, key string) error {
+	return c.inner.Remove(ctx, key)
+}
+
+// MGet 解密失败的条目按未命中处理
+func (c *encryptedCache) MGet(ctx context.Context, keys []string) ([]StringView, error) {
+	svs, err := c.inner.MGet(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for i, sv := range svs {
+		if sv.IsNil {
+			continue
+		}
+		svs[i], _ = c.decrypt(keys[i], sv)
+	}
+	return svs, nil
+}
+
+func (c *encryptedCache) MSet(ctx context.Context, keys []string, values []StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
+	svs, err := c.encryptAll(keys, values)
+	if err != nil {
+		return err
+	}
+	return c.inner.MSet(ctx, keys, svs, expiredTime, emptyExpiredTime)
+}
+
+func (c *encryptedCache) MSetWithTTL(ctx context.Context, keys []string, values []StringView, ttls []time.Duration) error {
+	svs, err := c.encryptAll(keys, values)
+	if err != nil {
+		return err
+	}
+	return msetWithTTL(ctx, c.inner, keys, svs, ttls)
+}
+
+func (c *encryptedCache) Scan(ctx context.Context, prefix string, batch int, fn func(keys []string) error) error {
+	scanner, ok := c.inner.(KeyScanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	return scanner.Scan(ctx, prefix, batch, fn)
+}
+
+func (c *encryptedCache) MRemove(ctx context.Context, keys []string) error {
+	scanner, ok := c.inner.(KeyScanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	return scanner.MRemove(ctx, keys)
+}
+
+func (c *encryptedCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	r, ok := c.inner.(TTLReader)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	return r.TTL(ctx, key)
+}
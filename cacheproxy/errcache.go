@@ -0,0 +1,126 @@
+package cacheproxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultErrorCacheSize 错误缓存默认最大条目数
+const defaultErrorCacheSize = 10000
+
+// ErrNotCacheable 回源函数返回该错误（可包装）表示本次结果不写入缓存，错误原样返回给调用方。
+// 不计为回源失败，不触发 WithOnBackSourceError，也不进入错误缓存
+var ErrNotCacheable = errors.New("back-source result not cacheable")
+
+// CachedError 回源失败后在 WithErrorCache 的有效期内直接返回的错误，Unwrap 得到原始错误
+type CachedError struct {
+	Err      error
+	CachedAt time.Time
+}
+
+func (e *CachedError) Error() string {
+	return "cached back-source error: " + e.Err.Error()
+}
+
+func (e *CachedError) Unwrap() error {
+	return e.Err
+}
+
+// WithErrorCache 回源失败后在 ttl 内不再回源，直接返回 *CachedError，避免数据源故障时每个请求都访问数据源。
+// 错误只缓存在进程内，与空值缓存相互独立；size 为最大条目数，<= 0 时取 10000
+func WithErrorCache(ttl time.Duration, size int) Option {
+	return func(o *options) {
+		o.errorCacheTTL = ttl
+		o.errorCacheSize = size
+	}
+}
+
+type errorEntry struct {
+	err      *CachedError
+	expireAt time.Time
+}
+
+// errorCache 按缓存 key 记录回源错误
+type errorCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	size  int
+	items map[string]errorEntry
+}
+
+func newErrorCache(ttl time.Duration, size int) *errorCache {
+	if size <= 0 {
+		size = defaultErrorCacheSize
+	}
+	return &errorCache{ttl: ttl, size: size, items: make(map[string]errorEntry)}
+}
+
+// get 返回 keys 中第一个仍在有效期内的错误
+func (c *errorCache) get(keys ...string) error {
+	if c == nil {
+		return nil
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		entry, ok := c.items[key]
+		if !ok {
+			continue
+		}
+		if entry.expireAt.Before(now) {
+			delete(c.items, key)
+			continue
+		}
+		return entry.err
+	}
+	return nil
+}
+
+// set 记录回源错误，限流、调用方取消或超时以及已缓存的错误不记录。
+// 调用方的 ctx 错误只说明该调用方放弃等待，不能让同一 key 的其他调用方也失败
+func (c *errorCache) set(err error, keys ...string) {
+	if c == nil || errors.Is(err, ErrThrottled) || errors.Is(err, ErrNotCacheable) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	var cached *CachedError
+	if errors.As(err, &cached) {
+		return
+	}
+	now := time.Now()
+	entry := errorEntry{err: &CachedError{Err: err, CachedAt: now}, expireAt: now.Add(c.ttl)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.items)+len(keys) > c.size {
+		for k, v := range c.items {
+			if v.expireAt.Before(now) {
+				delete(c.items, k)
+			}
+		}
+	}
+	for _, key := range keys {
+		if len(c.items) >= c.size {
+			return
+		}
+		c.items[key] = entry
+	}
+}
+
+func (c *errorCache) remove(keys ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.items, key)
+	}
+}
+
+// isQuietErr 后台刷新时无需记录日志的错误
+func isQuietErr(err error) bool {
+	var cached *CachedError
+	return errors.Is(err, ErrThrottled) || errors.Is(err, ErrNotCacheable) || errors.As(err, &cached)
+}
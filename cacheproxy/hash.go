@@ -0,0 +1,260 @@
+package cacheproxy
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// HashCache 可选接口，以 Redis 哈希存储结构化条目，每个字段可单独读写
+type HashCache interface {
+	// HGet 返回 fields 中已缓存的字段，未缓存的字段不在结果中
+	HGet(ctx context.Context, key string, fields []string) (map[string]string, error)
+	// HSet 写入字段，key 尚未设置过期时间时设置为 ttl
+	HSet(ctx context.Context, key string, values map[string]string, ttl time.Duration) error
+	HDel(ctx context.Context, key string, fields ...string) error
+}
+
+// FieldsGetter 按字段回源，未返回的字段以空值缓存，防止缓存穿透
+type FieldsGetter interface {
+	GetFields(ctx context.Context, key string, fields []string) (map[string]string, error)
+}
+
+type FieldsGetterFunc func(ctx context.Context, key string, fields []string) (map[string]string, error)
+
+func (f FieldsGetterFunc) GetFields(ctx context.Context, key string, fields []string) (map[string]string, error) {
+	return f(ctx, key, fields)
+}
+
+// HashProxy 以哈希存储大对象的缓存代理，可只刷新或更新其中一个字段，而不必重写整个 JSON。
+// key 前缀、命名空间与失效广播同 CacheProxy；不支持一级缓存与加密
+type HashProxy struct {
+	p     *CacheProxy
+	cache HashCache
+}
+
+// Hash 返回共享配置的哈希缓存代理，缓存不支持哈希时各方法返回 ErrNotSupported
+func (p *CacheProxy) Hash() *HashProxy {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	h := &HashProxy{p: p}
+	h.cache, _ = p.cache.(HashCache)
+	return h
+}
+
+// GetFields 返回 fields 的值，只对未缓存的字段回源并异步写入。值为空的字段不在结果中
+func (h *HashProxy) GetFields(ctx context.Context, c CacheContext, key string, fields []string, getter FieldsGetter) (map[string]string, error) {
+	if h.cache == nil {
+		return nil, ErrNotSupported
+	}
+	fields = validKeys(fields)
+	res := make(map[string]string, len(fields))
+	if len(key) == 0 || len(fields) == 0 {
+		return res, nil
+	}
+	cacheKey, err := h.p.cacheKey(ctx, c, key)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := h.cache.HGet(ctx, cacheKey, fields)
+	if err != nil {
+		return nil, err
+	}
+	var missed []string
+	for _, field := range fields {
+		v, ok := cached[field]
+		if !ok {
+			missed = append(missed, field)
+			continue
+		}
+		if len(v) > 0 {
+			res[field] = v
+		}
+	}
+	h.p.recordHit(len(fields) - len(missed))
+	if len(missed) == 0 {
+		return res, nil
+	}
+	missedNames := make([]string, len(missed))
+	for i, field := range missed {
+		missedNames[i] = key + "#" + field
+	}
+	h.p.recordMiss(missedNames...)
+	data, err := h.getFields(ctx, cacheKey, key, missed, getter)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(missed))
+	for _, field := range missed {
+		values[field] = data[field]
+		if v := data[field]; len(v) > 0 {
+			res[field] = v
+		}
+	}
+	h.p.async(func(asyncCtx context.Context) {
+		if setErr := h.cache.HSet(asyncCtx, cacheKey, values, c.jitter(c.ExpiredTime)); setErr != nil {
+			logger.Error("cacheProxy hash setErr:" + setErr.Error())
+		}
+	})
+	return res, nil
+}
+
+// getFields 以 cacheKey 与字段列表合并并发回源
+func (h *HashProxy) getFields(ctx context.Context, cacheKey string, key string, fields []string, getter FieldsGetter) (map[string]string, error) {
+	sorted := append([]string(nil), fields...)
+	sort.Strings(sorted)
+	val, err, _ := h.p.getGroup.Do(cacheKey+"\x00"+strings.Join(sorted, "\x00"), func() (interface{}, error) {
+		start := time.Now()
+		data, getErr := getter.GetFields(ctx, key, fields)
+		h.p.recordBackSource(time.Since(start), getErr)
+		if getErr != nil {
+			h.p.hooks.backSourceError(ctx, []string{key}, getErr)
+			return nil, getErr
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, _ := val.(map[string]string)
+	return data, nil
+}
+
+// SetFields 只更新指定字段并广播失效，其他字段保持不变
+func (h *HashProxy) SetFields(ctx context.Context, c CacheContext, key string, values map[string]string) error {
+	if h.cache == nil {
+		return ErrNotSupported
+	}
+	cacheKey, err := h.p.cacheKey(ctx, c, key)
+	if err != nil {
+		return err
+	}
+	if err = h.cache.HSet(ctx, cacheKey, values, c.jitter(c.ExpiredTime)); err != nil {
+		return err
+	}
+	h.p.publishInvalidate(ctx, c.Namespace, cacheKey)
+	return nil
+}
+
+// RemoveFields 删除指定字段，下次读取时重新回源
+func (h *HashProxy) RemoveFields(ctx context.Context, c CacheContext, key string, fields ...string) error {
+	if h.cache == nil {
+		return ErrNotSupported
+	}
+	cacheKey, err := h.p.cacheKey(ctx, c, key)
+	if err != nil {
+		return err
+	}
+	if err = h.cache.HDel(ctx, cacheKey, fields...); err != nil {
+		return err
+	}
+	h.p.publishInvalidate(ctx, c.Namespace, cacheKey)
+	return nil
+}
+
+// Remove 删除整个条目
+func (h *HashProxy) Remove(ctx context.Context, c CacheContext, key string) error {
+	return h.p.Remove(ctx, c, key)
+}
+
+// hsetScript 写入字段，key 未设置过期时间时设置过期时间
+var hsetScript = redis.NewScript(`
+redis.call("HSET", KEYS[1], unpack(ARGV, 2))
+if tonumber(ARGV[1]) > 0 and redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+func (c *RedisCache) HGet(ctx context.Context, key string, fields []string) (map[string]string, error) {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(key) <= 0 {
+		return nil, ErrInvalidKey
+	}
+	vals, err := c.rdb.HMGet(ctx, key, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(fields))
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			res[fields[i]] = s
+		}
+	}
+	return res, nil
+}
+
+func (c *RedisCache) HSet(ctx context.Context, key string, values map[string]string, ttl time.Duration) error {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(key) <= 0 {
+		return ErrInvalidKey
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, 1+len(values)*2)
+	args = append(args, ttl.Milliseconds())
+	for field, value := range values {
+		args = append(args, field, value)
+	}
+	return hsetScript.Run(ctx, c.rdb, []string{key}, args...).Err()
+}
+
+func (c *RedisCache) HDel(ctx context.Context, key string, fields ...string) error {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(key) <= 0 {
+		return ErrInvalidKey
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return c.rdb.HDel(ctx, key, fields...).Err()
+}
+
+func (c *ShardedCache) HGet(ctx context.Context, key string, fields []string) (map[string]string, error) {
+	return c.shard(key).HGet(ctx, key, fields)
+}
+
+func (c *ShardedCache) HSet(ctx context.Context, key string, values map[string]string, ttl time.Duration) error {
+	return c.shard(key).HSet(ctx, key, values, ttl)
+}
+
+func (c *ShardedCache) HDel(ctx context.Context, key string, fields ...string) error {
+	return c.shard(key).HDel(ctx, key, fields...)
+}
+
+// 哈希条目不经过一级缓存
+func (c *multiLevelCache) HGet(ctx context.Context, key string, fields []string) (map[string]string, error) {
+	hc, ok := c.l2.(HashCache)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return hc.HGet(ctx, key, fields)
+}
+
+func (c *multiLevelCache) HSet(ctx context.Context, key string, values map[string]string, ttl time.Duration) error {
+	hc, ok := c.l2.(HashCache)
+	if !ok {
+		return ErrNotSupported
+	}
+	return hc.HSet(ctx, key, values, ttl)
+}
+
+func (c *multiLevelCache) HDel(ctx context.Context, key string, fields ...string) error {
+	hc, ok := c.l2.(HashCache)
+	if !ok {
+		return ErrNotSupported
+	}
+	return hc.HDel(ctx, key, fields...)
+}
@@ -0,0 +1,75 @@
+package cacheproxy
+
+import (
+	"context"
+	"errors"
+)
+
+// hooks 缓存操作回调，用于接入自定义监控、链路追踪或日志，key 均为业务 key
+type hooks struct {
+	onHit             []func(ctx context.Context, key string)
+	onMiss            []func(ctx context.Context, key string)
+	onRefresh         []func(ctx context.Context, key string, force bool)
+	onBackSourceError []func(ctx context.Context, keys []string, err error)
+}
+
+// WithOnHit 缓存命中时回调，包括过期待刷新的命中
+func WithOnHit(fn func(ctx context.Context, key string)) Option {
+	return func(o *options) {
+		o.hooks.onHit = append(o.hooks.onHit, fn)
+	}
+}
+
+// WithOnMiss 缓存未命中时回调
+func WithOnMiss(fn func(ctx context.Context, key string)) Option {
+	return func(o *options) {
+		o.hooks.onMiss = append(o.hooks.onMiss, fn)
+	}
+}
+
+// WithOnRefresh 强制刷新或过期刷新时回调，force 表示强制刷新
+func WithOnRefresh(fn func(ctx context.Context, key string, force bool)) Option {
+	return func(o *options) {
+		o.hooks.onRefresh = append(o.hooks.onRefresh, fn)
+	}
+}
+
+// WithOnBackSourceError 回源失败时回调，批量回源时 keys 为该批次的全部 key
+func WithOnBackSourceError(fn func(ctx context.Context, keys []string, err error)) Option {
+	return func(o *options) {
+		o.hooks.onBackSourceError = append(o.hooks.onBackSourceError, fn)
+	}
+}
+
+func (h *hooks) hit(ctx context.Context, keys ...string) {
+	for _, fn := range h.onHit {
+		for _, key := range keys {
+			fn(ctx, key)
+		}
+	}
+}
+
+func (h *hooks) miss(ctx context.Context, keys ...string) {
+	for _, fn := range h.onMiss {
+		for _, key := range keys {
+			fn(ctx, key)
+		}
+	}
+}
+
+func (h *hooks) refresh(ctx context.Context, force bool, keys ...string) {
+	for _, fn := range h.onRefresh {
+		for _, key := range keys {
+			fn(ctx, key, force)
+		}
+	}
+}
+
+func (h *hooks) backSourceError(ctx context.Context, keys []string, err error) {
+	if errors.Is(err, ErrNotCacheable) {
+		return
+	}
+	for _, fn := range h.onBackSourceError {
+		fn(ctx, keys, err)
+	}
+}
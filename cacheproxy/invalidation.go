@@ -0,0 +1,157 @@
+package cacheproxy
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/bytedance/sonic"
+	"github.com/nats-io/nats.go"
+	"github.com/redis/go-redis/v9"
+)
+
+// Invalidator 跨实例广播缓存失效消息
+type Invalidator interface {
+	Publish(ctx context.Context, msg InvalidateMessage) error
+	// Subscribe 订阅失效消息，返回取消订阅函数
+	Subscribe(handler func(msg InvalidateMessage)) (func(), error)
+}
+
+type InvalidateMessage struct {
+	// Source 发送方实例 ID，接收方据此忽略自身发出的消息
+	Source string   `json:"source"`
+	Keys   []string `json:"keys"`
+	// Namespace Keys 所属的命名空间，接收方据此去掉 key 中的命名空间与版本号
+	Namespace string `json:"namespace,omitempty"`
+	// Namespaces BumpVersion 递增版本号的命名空间，接收方清除缓存的版本号
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// RedisInvalidator 基于 Redis Pub/Sub 的失效广播
+type RedisInvalidator struct {
+	rdb     redis.UniversalClient
+	channel string
+}
+
+func NewRedisInvalidator(rdb redis.UniversalClient, channel string) *RedisInvalidator {
+	return &RedisInvalidator{rdb: rdb, channel: channel}
+}
+
+func (i *RedisInvalidator) Publish(ctx context.Context, msg InvalidateMessage) error {
+	payload, err := sonic.MarshalString(msg)
+	if err != nil {
+		return err
+	}
+	return i.rdb.Publish(ctx, i.channel, payload).Err()
+}
+
+func (i *RedisInvalidator) Subscribe(handler func(msg InvalidateMessage)) (func(), error) {
+	pubsub := i.rdb.Subscribe(context.Background(), i.channel)
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		_ = pubsub.Close()
+		return func() {}, err
+	}
+	go func() {
+		for m := range pubsub.Channel() {
+			var msg InvalidateMessage
+			if err := sonic.UnmarshalString(m.Payload, &msg); err != nil {
+				logger.Error("cacheProxy invalidate message err:" + err.Error())
+				continue
+			}
+			handler(msg)
+		}
+	}()
+	return func() {
+		_ = pubsub.Close()
+	}, nil
+}
+
+// NatsInvalidator 基于 NATS subject 的失效广播
+type NatsInvalidator struct {
+	nc      *nats.Conn
+	subject string
+}
+
+func NewNatsInvalidator(nc *nats.Conn, subject string) *NatsInvalidator {
+	return &NatsInvalidator{nc: nc, subject: subject}
+}
+
+func (i *NatsInvalidator) Publish(ctx context.Context, msg InvalidateMessage) error {
+	payload, err := sonic.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return i.nc.Publish(i.subject, payload)
+}
+
+func (i *NatsInvalidator) Subscribe(handler func(msg InvalidateMessage)) (func(), error) {
+	sub, err := i.nc.Subscribe(i.subject, func(m *nats.Msg) {
+		var msg InvalidateMessage
+		if err := sonic.Unmarshal(m.Data, &msg); err != nil {
+			logger.Error("cacheProxy invalidate message err:" + err.Error())
+			return
+		}
+		handler(msg)
+	})
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {
+		_ = sub.Unsubscribe()
+	}, nil
+}
+
+// publishInvalidate 广播失效消息，失败只记录日志。ns 为 cacheKeys 所属的命名空间，未知时为空
+func (p *CacheProxy) publishInvalidate(ctx context.Context, ns string, cacheKeys ...string) {
+	if p.invalidator == nil {
+		return
+	}
+	err := p.invalidator.Publish(ctx, InvalidateMessage{Source: p.instanceID, Keys: cacheKeys, Namespace: ns})
+	if err != nil {
+		logger.Error("cacheProxy publish invalidate err:" + err.Error())
+	}
+}
+
+// onInvalidate 收到其他实例的失效消息，清除一级缓存并通知业务
+func (p *CacheProxy) onInvalidate(msg InvalidateMessage) {
+	if msg.Source == p.instanceID {
+		return
+	}
+	if len(msg.Namespaces) > 0 {
+		p.forgetVersions(msg.Namespaces)
+	}
+	keys := make([]string, 0, len(msg.Keys))
+	for _, key := range msg.Keys {
+		// 忽略共用频道的其他服务的 key
+		if !strings.HasPrefix(key, p.keyPrefix) {
+			continue
+		}
+		if p.l1 != nil {
+			p.l1.Remove(key)
+		}
+		keys = append(keys, stripVersion(msg.Namespace, strings.TrimPrefix(key, p.keyPrefix)))
+	}
+	if p.invalidateHandler != nil && len(keys) > 0 {
+		p.invalidateHandler(keys)
+	}
+}
+
+// stripVersion 去掉 versionedKey 加上的 "命名空间:v版本号:"，得到业务 key
+func stripVersion(ns string, key string) string {
+	if ns == "" {
+		return key
+	}
+	rest, ok := strings.CutPrefix(key, ns+":v")
+	if !ok {
+		return key
+	}
+	version, bizKey, ok := strings.Cut(rest, ":")
+	if !ok {
+		return key
+	}
+	if _, err := strconv.ParseInt(version, 10, 64); err != nil {
+		return key
+	}
+	return bizKey
+}
@@ -0,0 +1,328 @@
+package cacheproxy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// leaderboardSentinel 占位成员，分数为负无穷，使数据源为空时排行榜仍然存在，避免反复重建
+const leaderboardSentinel = "\x00"
+
+// ScoredMember 排行榜成员及分数
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// SortedSetCache 可选接口，以 Redis 有序集合存储排行榜
+type SortedSetCache interface {
+	ZExists(ctx context.Context, key string) (bool, error)
+	// ZReplace 以 members 整体替换有序集合并设置过期时间
+	ZReplace(ctx context.Context, key string, members []ScoredMember, ttl time.Duration) error
+	// ZIncrBy 有序集合存在时为成员增加分数，不存在时 ok 为 false 且不创建，避免创建出没有过期时间的 key
+	ZIncrBy(ctx context.Context, key string, member string, delta float64) (score float64, ok bool, err error)
+	// ZRevRange 按分数从高到低返回 [start, stop] 区间的成员
+	ZRevRange(ctx context.Context, key string, start int64, stop int64) ([]ScoredMember, error)
+	// ZRevRank 返回成员从高到低的排名（从 0 开始）与分数，成员不存在时 ok 为 false
+	ZRevRank(ctx context.Context, key string, member string) (rank int64, score float64, ok bool, err error)
+}
+
+// LeaderboardLoader 从数据源加载完整排行榜
+type LeaderboardLoader interface {
+	Load(ctx context.Context, key string) ([]ScoredMember, error)
+}
+
+type LeaderboardLoaderFunc func(ctx context.Context, key string) ([]ScoredMember, error)
+
+func (f LeaderboardLoaderFunc) Load(ctx context.Context, key string) ([]ScoredMember, error) {
+	return f(ctx, key)
+}
+
+// Leaderboard 排行榜缓存，不存在时与 GetHit 一样合并并发请求，从数据源重建后再读写
+type Leaderboard struct {
+	p      *CacheProxy
+	cache  SortedSetCache
+	c      CacheContext
+	key    string
+	loader LeaderboardLoader
+}
+
+// Leaderboard 返回 key 对应的排行榜，重建后按 c.ExpiredTime 过期。缓存不支持有序集合时各方法返回 ErrNotSupported
+func (p *CacheProxy) Leaderboard(c CacheContext, key string, loader LeaderboardLoader) *Leaderboard {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	l := &Leaderboard{p: p, c: c, key: key, loader: loader}
+	l.cache, _ = p.cache.(SortedSetCache)
+	return l
+}
+
+// AddScore 为成员增加分数并返回新分数。排行榜不存在或在两次调用之间过期时先重建再增加，
+// 重建后仍不存在时返回 ErrKeyNotFound
+func (l *Leaderboard) AddScore(ctx context.Context, member string, delta float64) (float64, error) {
+	if l.cache == nil {
+		return 0, ErrNotSupported
+	}
+	cacheKey, err := l.p.cacheKey(ctx, l.c, l.key)
+	if err != nil {
+		return 0, err
+	}
+	score, ok, err := l.cache.ZIncrBy(ctx, cacheKey, member, delta)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		l.p.recordHit(1)
+		return score, nil
+	}
+	l.p.recordMiss(l.key)
+	if err = l.rebuild(ctx, cacheKey); err != nil {
+		return 0, err
+	}
+	score, ok, err = l.cache.ZIncrBy(ctx, cacheKey, member, delta)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrKeyNotFound
+	}
+	return score, nil
+}
+
+// TopN 返回分数最高的 n 个成员
+func (l *Leaderboard) TopN(ctx context.Context, n int) ([]ScoredMember, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	cacheKey, err := l.ensure(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := l.cache.ZRevRange(ctx, cacheKey, 0, int64(n)-1)
+	if err != nil {
+		return nil, err
+	}
+	res := members[:0]
+	for _, m := range members {
+		if m.Member != leaderboardSentinel {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+// Rank 返回成员从高到低的排名（从 0 开始）与分数，成员不存在时 ok 为 false
+func (l *Leaderboard) Rank(ctx context.Context, member string) (int64, float64, bool, error) {
+	if member == leaderboardSentinel {
+		return 0, 0, false, nil
+	}
+	cacheKey, err := l.ensure(ctx)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return l.cache.ZRevRank(ctx, cacheKey, member)
+}
+
+// Rebuild 立即从数据源重建排行榜
+func (l *Leaderboard) Rebuild(ctx context.Context) error {
+	if l.cache == nil {
+		return ErrNotSupported
+	}
+	cacheKey, err := l.p.cacheKey(ctx, l.c, l.key)
+	if err != nil {
+		return err
+	}
+	return l.rebuild(ctx, cacheKey)
+}
+
+// ensure 排行榜不存在时重建，返回缓存 key
+func (l *Leaderboard) ensure(ctx context.Context) (string, error) {
+	if l.cache == nil {
+		return "", ErrNotSupported
+	}
+	cacheKey, err := l.p.cacheKey(ctx, l.c, l.key)
+	if err != nil {
+		return "", err
+	}
+	exist, err := l.cache.ZExists(ctx, cacheKey)
+	if err != nil {
+		return "", err
+	}
+	if exist {
+		l.p.recordHit(1)
+		return cacheKey, nil
+	}
+	l.p.recordMiss(l.key)
+	return cacheKey, l.rebuild(ctx, cacheKey)
+}
+
+func (l *Leaderboard) rebuild(ctx context.Context, cacheKey string) error {
+	// 与字符串条目的回源区分开
+	_, err, _ := l.p.getGroup.Do("zset\x00"+cacheKey, func() (interface{}, error) {
+		start := time.Now()
+		members, loadErr := l.loader.Load(ctx, l.key)
+		l.p.recordBackSource(time.Since(start), loadErr)
+		if loadErr != nil {
+			l.p.hooks.backSourceError(ctx, []string{l.key}, loadErr)
+			return nil, loadErr
+		}
+		members = append(members, ScoredMember{Member: leaderboardSentinel, Score: math.Inf(-1)})
+		return nil, l.cache.ZReplace(ctx, cacheKey, members, l.c.jitter(l.c.ExpiredTime))
+	})
+	return err
+}
+
+// zincrbyScript 有序集合存在时才增加分数，不存在时返回 false
+var zincrbyScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+return redis.call("ZINCRBY", KEYS[1], ARGV[1], ARGV[2])
+`)
+
+func (c *RedisCache) ZExists(ctx context.Context, key string) (bool, error) {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	n, err := c.rdb.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (c *RedisCache) ZReplace(ctx context.Context, key string, members []ScoredMember, ttl time.Duration) error {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	if len(key) <= 0 {
+		return ErrInvalidKey
+	}
+	zs := make([]redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = redis.Z{Score: m.Score, Member: m.Member}
+	}
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, zs...)
+		if ttl > 0 {
+			pipe.PExpire(ctx, key, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *RedisCache) ZIncrBy(ctx context.Context, key string, member string, delta float64) (float64, bool, error) {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	score, err := zincrbyScript.Run(ctx, c.rdb, []string{key}, delta, member).Float64()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return score, true, nil
+}
+
+func (c *RedisCache) ZRevRange(ctx context.Context, key string, start int64, stop int64) ([]ScoredMember, error) {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	zs, err := c.rdb.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]ScoredMember, len(zs))
+	for i, z := range zs {
+		res[i] = ScoredMember{Score: z.Score}
+		res[i].Member, _ = z.Member.(string)
+	}
+	return res, nil
+}
+
+func (c *RedisCache) ZRevRank(ctx context.Context, key string, member string) (int64, float64, bool, error) {
+	if c.rdb == nil {
+		panic("empty redis client")
+	}
+	rs, err := c.rdb.ZRevRankWithScore(ctx, key, member).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, 0, false, nil
+	}
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return rs.Rank, rs.Score, true, nil
+}
+
+func (c *ShardedCache) ZExists(ctx context.Context, key string) (bool, error) {
+	return c.shard(key).ZExists(ctx, key)
+}
+
+func (c *ShardedCache) ZReplace(ctx context.Context, key string, members []ScoredMember, ttl time.Duration) error {
+	return c.shard(key).ZReplace(ctx, key, members, ttl)
+}
+
+func (c *ShardedCache) ZIncrBy(ctx context.Context, key string, member string, delta float64) (float64, bool, error) {
+	return c.shard(key).ZIncrBy(ctx, key, member, delta)
+}
+
+func (c *ShardedCache) ZRevRange(ctx context.Context, key string, start int64, stop int64) ([]ScoredMember, error) {
+	return c.shard(key).ZRevRange(ctx, key, start, stop)
+}
+
+func (c *ShardedCache) ZRevRank(ctx context.Context, key string, member string) (int64, float64, bool, error) {
+	return c.shard(key).ZRevRank(ctx, key, member)
+}
+
+// 有序集合不经过一级缓存
+func (c *multiLevelCache) sortedSet() (SortedSetCache, error) {
+	sc, ok := c.l2.(SortedSetCache)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return sc, nil
+}
+
+func (c *multiLevelCache) ZExists(ctx context.Context, key string) (bool, error) {
+	sc, err := c.sortedSet()
+	if err != nil {
+		return false, err
+	}
+	return sc.ZExists(ctx, key)
+}
+
+func (c *multiLevelCache) ZReplace(ctx context.Context, key string, members []ScoredMember, ttl time.Duration) error {
+	sc, err := c.sortedSet()
+	if err != nil {
+		return err
+	}
+	return sc.ZReplace(ctx, key, members, ttl)
+}
+
+func (c *multiLevelCache) ZIncrBy(ctx context.Context, key string, member string, delta float64) (float64, bool, error) {
+	sc, err := c.sortedSet()
+	if err != nil {
+		return 0, false, err
+	}
+	return sc.ZIncrBy(ctx, key, member, delta)
+}
+
+func (c *multiLevelCache) ZRevRange(ctx context.Context, key string, start int64, stop int64) ([]ScoredMember, error) {
+	sc, err := c.sortedSet()
+	if err != nil {
+		return nil, err
+	}
+	return sc.ZRevRange(ctx, key, start, stop)
+}
+
+func (c *multiLevelCache) ZRevRank(ctx context.Context, key string, member string) (int64, float64, bool, error) {
+	sc, err := c.sortedSet()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return sc.ZRevRank(ctx, key, member)
+}
@@ -0,0 +1,151 @@
+package cacheproxy
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+)
+
+// ErrThrottled 回源超过限流阈值。过期刷新被限流时继续使用旧值，没有旧值时返回该错误
+var ErrThrottled = errors.New("back-source throttled")
+
+// limiterCleanupSize 单 key 令牌桶数量超过该值时清理已回满的令牌桶
+const limiterCleanupSize = 10000
+
+// tokenBucket 令牌桶，rate 为每秒生成的令牌数，容量为 burst
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	burst := math.Max(rate, 1)
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refund 归还 allow 取走的令牌
+func (b *tokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// full 令牌桶已回满，与新建的令牌桶等价
+func (b *tokenBucket) full(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// backSourceLimiter 全局与单 key 两级回源限流
+type backSourceLimiter struct {
+	global     *tokenBucket
+	perKeyRate float64
+
+	mu   sync.Mutex
+	keys map[string]*tokenBucket
+}
+
+func newBackSourceLimiter(globalQPS float64, perKeyQPS float64) *backSourceLimiter {
+	l := &backSourceLimiter{perKeyRate: perKeyQPS}
+	if globalQPS > 0 {
+		l.global = newTokenBucket(globalQPS)
+	}
+	if perKeyQPS > 0 {
+		l.keys = make(map[string]*tokenBucket)
+	}
+	return l
+}
+
+// allow 一次回源消耗一个全局令牌，并为每个 key 消耗一个单 key 令牌。
+// 先检查单 key 令牌，最后取全局令牌，被拒绝时归还已取走的令牌，避免热点 key 被限流时耗尽全局配额
+func (l *backSourceLimiter) allow(keys ...string) bool {
+	if l == nil {
+		return true
+	}
+	var taken []*tokenBucket
+	if l.keys != nil {
+		taken = make([]*tokenBucket, 0, len(keys))
+		for _, key := range keys {
+			b := l.bucket(key)
+			if !b.allow() {
+				refundAll(taken)
+				return false
+			}
+			taken = append(taken, b)
+		}
+	}
+	if l.global != nil && !l.global.allow() {
+		refundAll(taken)
+		return false
+	}
+	return true
+}
+
+func refundAll(buckets []*tokenBucket) {
+	for _, b := range buckets {
+		b.refund()
+	}
+}
+
+func (l *backSourceLimiter) bucket(key string) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.keys[key]
+	if ok {
+		return b
+	}
+	if len(l.keys) >= limiterCleanupSize {
+		now := time.Now()
+		for k, v := range l.keys {
+			if v.full(now) {
+				delete(l.keys, k)
+			}
+		}
+	}
+	b = newTokenBucket(l.perKeyRate)
+	l.keys[key] = b
+	return b
+}
+
+// WithBackSourceLimit 限制回源 QPS，globalQPS 为整个实例的回源次数，perKeyQPS 为单个 key 的回源次数，0 表示不限制。
+// 缓存冷启动或 Redis 被清空时保护数据源：过期刷新被限流时继续返回旧值，没有可用旧值时返回 ErrThrottled。
+// 批量回源时任意一个 key 被限流则整批返回 ErrThrottled
+func WithBackSourceLimit(globalQPS float64, perKeyQPS float64) Option {
+	return func(o *options) {
+		o.globalQPS = globalQPS
+		o.perKeyQPS = perKeyQPS
+	}
+}
+
+// allowBackSource 检查回源限流，被限流时记录监控
+func (p *CacheProxy) allowBackSource(keys ...string) bool {
+	if p.limiter.allow(keys...) {
+		return true
+	}
+	metrics.CacheThrottledMetric(p.name, len(keys))
+	return false
+}
@@ -0,0 +1,157 @@
+package cacheproxy
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	localShardCount = 64
+	// 每个分片写入多少次后清理一次过期条目
+	localSweepEvery = 1024
+)
+
+// LocalCache 纯内存的 Cache 实现，适用于单测以及无 Redis 的小型服务
+type LocalCache struct {
+	shards [localShardCount]*localShard
+}
+
+type localShard struct {
+	mu     sync.RWMutex
+	items  map[string]localItem
+	writes int
+}
+
+type localItem struct {
+	value    StringView
+	expireAt time.Time
+}
+
+func (i localItem) expired(now time.Time) bool {
+	return !i.expireAt.IsZero() && i.expireAt.Before(now)
+}
+
+func NewLocalAdaptor() *LocalCache {
+	c := &LocalCache{}
+	for i := range c.shards {
+		c.shards[i] = &localShard{items: make(map[string]localItem)}
+	}
+	return c
+}
+
+func (c *LocalCache) Get(ctx context.Context, key string) (StringView, bool, error) {
+	if len(key) <= 0 {
+		return StringView{}, false, ErrInvalidKey
+	}
+	sv, ok := c.shard(key).get(key)
+	if !ok {
+		return StringView{IsNil: true}, false, nil
+	}
+	return sv, true, nil
+}
+
+func (c *LocalCache) Set(ctx context.Context, key string, value StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
+	if len(key) <= 0 {
+		return ErrInvalidKey
+	}
+	expired := expiredTime
+	if len(value.Data) == 0 {
+		expired = emptyExpiredTime
+	}
+	c.shard(key).set(key, value, expired)
+	return nil
+}
+
+func (c *LocalCache) Remove(ctx context.Context, key string) error {
+	if len(key) <= 0 {
+		return ErrInvalidKey
+	}
+	shard := c.shard(key)
+	shard.mu.Lock()
+	delete(shard.items, key)
+	shard.mu.Unlock()
+	return nil
+}
+
+func (c *LocalCache) MGet(ctx context.Context, keys []string) ([]StringView, error) {
+	res := make([]StringView, len(keys))
+	for i, key := range keys {
+		sv, _, err := c.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = sv
+	}
+	return res, nil
+}
+
+func (c *LocalCache) MSet(ctx context.Context, keys []string, values []StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
+	if len(keys) != len(values) {
+		return ErrMismatchedPair
+	}
+	for i, key := range keys {
+		if err := c.Set(ctx, key, values[i], expiredTime, emptyExpiredTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *LocalCache) MSetWithTTL(ctx context.Context, keys []string, values []StringView, ttls []time.Duration) error {
+	if len(keys) != len(values) || len(keys) != len(ttls) {
+		return ErrMismatchedPair
+	}
+	for i, key := range keys {
+		if err := c.Set(ctx, key, values[i], ttls[i], ttls[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *LocalCache) shard(key string) *localShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return c.shards[h.Sum32()%localShardCount]
+}
+
+func (s *localShard) get(key string) (StringView, bool) {
+	s.mu.RLock()
+	item, ok := s.items[key]
+	s.mu.RUnlock()
+	if !ok {
+		return StringView{}, false
+	}
+	if item.expired(time.Now()) {
+		s.mu.Lock()
+		if item, ok = s.items[key]; ok && item.expired(time.Now()) {
+			delete(s.items, key)
+		}
+		s.mu.Unlock()
+		return StringView{}, false
+	}
+	return item.value, true
+}
+
+// set ttl <= 0 表示不过期
+func (s *localShard) set(key string, value StringView, ttl time.Duration) {
+	item := localItem{value: value}
+	if ttl > 0 {
+		item.expireAt = time.Now().Add(ttl)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = item
+	s.writes++
+	if s.writes >= localSweepEvery {
+		s.writes = 0
+		now := time.Now()
+		for k, v := range s.items {
+			if v.expired(now) {
+				delete(s.items, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cacheproxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	refreshLockSuffix   = ":refresh_lock"
+	refreshWaitInterval = 20 * time.Millisecond
+)
+
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// refreshLock 基于 SET NX 的分布式回源锁，保证同一时刻集群内只有一个实例回源同一个 key
+type refreshLock struct {
+	rdb  redis.Cmdable
+	ttl  time.Duration
+	wait time.Duration
+}
+
+// TryLock 尝试加锁，成功时返回解锁函数。Redis 异常时视为加锁成功，避免影响回源
+func (l *refreshLock) TryLock(ctx context.Context, key string) (func(), bool) {
+	lockKey := key + refreshLockSuffix
+	token := randomID()
+	ok, err := l.rdb.SetNX(ctx, lockKey, token, l.ttl).Result()
+	if err != nil {
+		logger.Error("cacheProxy refresh lock err:" + err.Error())
+		return func() {}, true
+	}
+	if !ok {
+		return func() {}, false
+	}
+	return func() {
+		err := unlockScript.Run(context.Background(), l.rdb, []string{lockKey}, token).Err()
+		if err != nil {
+			logger.Error("cacheProxy refresh unlock err:" + err.Error())
+		}
+	}, true
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// tryRefreshLock 未开启分布式锁时总是成功
+func (p *CacheProxy) tryRefreshLock(ctx context.Context, cacheKey string) (func(), bool) {
+	if p.refreshLock == nil {
+		return func() {}, true
+	}
+	return p.refreshLock.TryLock(ctx, cacheKey)
+}
+
+// waitRefreshed 其他实例持有锁时，等待其写入缓存，超时返回 false
+func (p *CacheProxy) waitRefreshed(ctx context.Context, cacheKey string) (StringView, bool) {
+	timer := time.NewTimer(p.refreshLock.wait)
+	defer timer.Stop()
+	ticker := time.NewTicker(refreshWaitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return StringView{}, false
+		case <-timer.C:
+			return StringView{}, false
+		case <-ticker.C:
+			sv, exist, err := p.cache.Get(ctx, cacheKey)
+			if err != nil {
+				return StringView{}, false
+			}
+			if exist {
+				return sv, true
+			}
+		}
+	}
+}
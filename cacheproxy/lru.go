@@ -0,0 +1,79 @@
+package cacheproxy
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruCache 带过期时间的进程内 LRU 缓存
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key      string
+	value    StringView
+	expireAt time.Time
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *lruCache) Get(key string) (StringView, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ele, ok := c.items[key]
+	if !ok {
+		return StringView{}, false
+	}
+	entry := ele.Value.(*lruEntry)
+	if !entry.expireAt.IsZero() && entry.expireAt.Before(time.Now()) {
+		c.removeElement(ele)
+		return StringView{}, false
+	}
+	c.ll.MoveToFront(ele)
+	return entry.value, true
+}
+
+// Set ttl <= 0 表示不过期，仅受容量淘汰
+func (c *lruCache) Set(key string, value StringView, ttl time.Duration) {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ele, ok := c.items[key]; ok {
+		entry := ele.Value.(*lruEntry)
+		entry.value = value
+		entry.expireAt = expireAt
+		c.ll.MoveToFront(ele)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expireAt: expireAt})
+	for c.size > 0 && c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *lruCache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ele, ok := c.items[key]; ok {
+		c.removeElement(ele)
+	}
+}
+
+func (c *lruCache) removeElement(ele *list.Element) {
+	c.ll.Remove(ele)
+	delete(c.items, ele.Value.(*lruEntry).key)
+}
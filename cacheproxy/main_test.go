@@ -0,0 +1,32 @@
+package cacheproxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+)
+
+// TestMain 把日志写到临时目录，熔断器等路径会调用全局 logger
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "cacheproxy-test")
+	if err != nil {
+		panic(err)
+	}
+	channel := func(name string) logger.ChannelConfig {
+		return logger.ChannelConfig{Filename: filepath.Join(dir, name+".log"), MaxSize: 1}
+	}
+	logger.InitLoggerWithConfig(logger.LoggerConfig{
+		Info:   channel("info"),
+		Error:  channel("error"),
+		Access: channel("access"),
+		Panic:  channel("panic"),
+		Dal:    channel("dal"),
+		Audit:  channel("audit"),
+	})
+	code := m.Run()
+	logger.StopRotation()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
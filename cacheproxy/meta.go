@@ -0,0 +1,49 @@
+package cacheproxy
+
+import "time"
+
+// CacheStatus 结果来源
+type CacheStatus int
+
+const (
+	// CacheStatusFresh 缓存命中且未过期
+	CacheStatusFresh CacheStatus = iota
+	// CacheStatusStale 缓存命中但已过期，返回旧值并在后台刷新
+	CacheStatusStale
+	// CacheStatusFetched 缓存未命中或强制刷新，回源获取
+	CacheStatusFetched
+)
+
+func (s CacheStatus) String() string {
+	switch s {
+	case CacheStatusFresh:
+		return "HIT"
+	case CacheStatusStale:
+		return "STALE"
+	default:
+		return "MISS"
+	}
+}
+
+// ResultMeta GetHitWithMeta 返回的结果元信息
+type ResultMeta struct {
+	Status CacheStatus
+	// Age 缓存条目自写入以来的时长，回源获取时为 0
+	Age time.Duration
+	// Ctime 缓存条目的写入时间，回源获取时为零值
+	Ctime time.Time
+	// NeedFastRequery 缓存条目是否使用 FastRefreshOffset 刷新
+	NeedFastRequery bool
+	// TTL 缓存条目在 Redis 中的剩余过期时间，小于 0 表示不过期。仅 GetWithMeta 填充，回源获取时为 0
+	TTL time.Duration
+	// Truncated 缓存的值超过 WithMaxValueSize 限制被 OversizeTruncate 截断，不是完整的值
+	Truncated bool
+}
+
+func newResultMeta(status CacheStatus, sv StringView) ResultMeta {
+	meta := ResultMeta{Status: status, Ctime: sv.Ctime, NeedFastRequery: sv.NeedFastRequery, Truncated: sv.Truncated}
+	if !sv.Ctime.IsZero() {
+		meta.Age = time.Since(sv.Ctime)
+	}
+	return meta
+}
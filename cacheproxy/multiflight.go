@@ -0,0 +1,97 @@
+package cacheproxy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// multiCall 单个 key 的批量回源结果
+type multiCall struct {
+	done  chan struct{}
+	val   string
+	delta time.Duration
+	err   error
+}
+
+// multiFlight 批量版 singleflight：并发的批量请求中，同一个 key 只由第一个请求回源，
+// 其余请求等待其结果，只为尚未在回源中的 key 调用 getter
+type multiFlight struct {
+	mu    sync.Mutex
+	calls map[string]*multiCall
+}
+
+func newMultiFlight() *multiFlight {
+	return &multiFlight{calls: make(map[string]*multiCall)}
+}
+
+// do 以 cacheKeys 合并并发回源，fn 使用业务 key，返回值同 getMissedResource
+func (g *multiFlight) do(ctx context.Context, keys []string, cacheKeys []string,
+	fn func(keys []string) (map[string]string, time.Duration, error)) (map[string]string, time.Duration, error) {
+	var own, ownCacheKeys []string
+	var ownCalls []*multiCall
+	waits := make(map[string]*multiCall)
+	g.mu.Lock()
+	for i, cacheKey := range cacheKeys {
+		if c, ok := g.calls[cacheKey]; ok {
+			waits[keys[i]] = c
+			continue
+		}
+		c := &multiCall{done: make(chan struct{})}
+		g.calls[cacheKey] = c
+		own = append(own, keys[i])
+		ownCacheKeys = append(ownCacheKeys, cacheKey)
+		ownCalls = append(ownCalls, c)
+	}
+	g.mu.Unlock()
+
+	res := make(map[string]string, len(keys))
+	var delta time.Duration
+	if len(own) > 0 {
+		data, d, err := g.call(own, ownCacheKeys, ownCalls, fn)
+		if err != nil {
+			return nil, d, err
+		}
+		for key, v := range data {
+			res[key] = v
+		}
+		delta = d
+	}
+	for key, c := range waits {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, delta, ctx.Err()
+		}
+		if c.err != nil {
+			return nil, delta, c.err
+		}
+		res[key] = c.val
+		delta = max(delta, c.delta)
+	}
+	return res, delta, nil
+}
+
+// call 为本请求负责的 key 回源，并将结果通知等待中的请求
+func (g *multiFlight) call(keys []string, cacheKeys []string, calls []*multiCall,
+	fn func(keys []string) (map[string]string, time.Duration, error)) (data map[string]string, delta time.Duration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cacheProxy multi getter panic: %v", r)
+		}
+		g.mu.Lock()
+		for _, cacheKey := range cacheKeys {
+			delete(g.calls, cacheKey)
+		}
+		g.mu.Unlock()
+		for i, c := range calls {
+			c.val, c.delta, c.err = data[keys[i]], delta, err
+			close(c.done)
+		}
+		if err != nil {
+			data = nil
+		}
+	}()
+	return fn(keys)
+}
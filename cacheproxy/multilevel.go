@@ -0,0 +1,113 @@
+package cacheproxy
+
+import (
+	"context"
+	"time"
+)
+
+// multiLevelCache 在 Redis 前增加进程内一级缓存，二级缓存命中时自动填充一级缓存
+type multiLevelCache struct {
+	l1    *lruCache
+	l1TTL time.Duration
+	l2    Cache
+}
+
+func newMultiLevelCache(l2 Cache, size int, ttl time.Duration) *multiLevelCache {
+	return &multiLevelCache{
+		l1:    newLRUCache(size),
+		l1TTL: ttl,
+		l2:    l2,
+	}
+}
+
+func (c *multiLevelCache) Get(ctx context.Context, key string) (StringView, bool, error) {
+	if sv, ok := c.l1.Get(key); ok {
+		return sv, true, nil
+	}
+	sv, exist, err := c.l2.Get(ctx, key)
+	if err != nil || !exist {
+		return sv, exist, err
+	}
+	c.l1.Set(key, sv, c.l1TTL)
+	return sv, true, nil
+}
+
+func (c *multiLevelCache) Set(ctx context.Context, key string, value StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
+	if err := c.l2.Set(ctx, key, value, expiredTime, emptyExpiredTime); err != nil {
+		c.l1.Remove(key)
+		return err
+	}
+	c.l1.Set(key, value, c.ttl(value, expiredTime, emptyExpiredTime))
+	return nil
+}
+
+func (c *multiLevelCache) Remove(ctx context.Context, key string) error {
+	c.l1.Remove(key)
+	return c.l2.Remove(ctx, key)
+}
+
+func (c *multiLevelCache) MGet(ctx context.Context, keys []string) ([]StringView, error) {
+	res := make([]StringView, len(keys))
+	var missed []string
+	var missedIdx []int
+	for i, key := range keys {
+		if sv, ok := c.l1.Get(key); ok {
+			res[i] = sv
+			continue
+		}
+		missed = append(missed, key)
+		missedIdx = append(missedIdx, i)
+	}
+	if len(missed) == 0 {
+		return res, nil
+	}
+	svs, err := c.l2.MGet(ctx, missed)
+	if err != nil {
+		return nil, err
+	}
+	for i, sv := range svs {
+		res[missedIdx[i]] = sv
+		if !sv.IsNil {
+			c.l1.Set(missed[i], sv, c.l1TTL)
+		}
+	}
+	return res, nil
+}
+
+func (c *multiLevelCache) MSet(ctx context.Context, keys []string, values []StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
+	if err := c.l2.MSet(ctx, keys, values, expiredTime, emptyExpiredTime); err != nil {
+		for _, key := range keys {
+			c.l1.Remove(key)
+		}
+		return err
+	}
+	for i, key := range keys {
+		c.l1.Set(key, values[i], c.ttl(values[i], expiredTime, emptyExpiredTime))
+	}
+	return nil
+}
+
+// ttl 一级缓存有效期不超过二级缓存
+func (c *multiLevelCache) ttl(value StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) time.Duration {
+	expired := expiredTime
+	if len(value.Data) == 0 {
+		expired = emptyExpiredTime
+	}
+	if expired > 0 && (c.l1TTL <= 0 || expired < c.l1TTL) {
+		return expired
+	}
+	return c.l1TTL
+}
+
+func (c *multiLevelCache) MSetWithTTL(ctx context.Context, keys []string, values []StringView, ttls []time.Duration) error {
+	if err := msetWithTTL(ctx, c.l2, keys, values, ttls); err != nil {
+		for _, key := range keys {
+			c.l1.Remove(key)
+		}
+		return err
+	}
+	for i, key := range keys {
+		c.l1.Set(key, values[i], c.ttl(values[i], ttls[i], ttls[i]))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package cacheproxy
+
+import (
+	"crypto/cipher"
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	name      string
+	cache     Cache
+	keyPrefix string
+
+	workers    int
+	queueSize  int
+	dropPolicy DropPolicy
+
+	l1Size int
+	l1TTL  time.Duration
+
+	lockTTL  time.Duration
+	lockWait time.Duration
+
+	invalidator       Invalidator
+	invalidateHandler func(keys []string)
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+
+	maxValueSize   int
+	oversizePolicy OversizePolicy
+
+	writeBehindWriter   BatchSourceWriter
+	writeBehindBatch    int
+	writeBehindInterval time.Duration
+
+	hooks hooks
+	bloom BloomFilter
+
+	globalQPS float64
+	perKeyQPS float64
+
+	aead cipher.AEAD
+
+	asyncTimeout time.Duration
+
+	errorCacheTTL  time.Duration
+	errorCacheSize int
+
+	versionCacheTTL time.Duration
+}
+
+// WithName 设置实例名称，作为监控指标的 name 标签，默认 "default"
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithL1 启用进程内一级缓存，size 为最大条目数，ttl 为一级缓存有效期
+func WithL1(size int, ttl time.Duration) Option {
+	return func(o *options) {
+		o.l1Size = size
+		o.l1TTL = ttl
+	}
+}
+
+// WithRefreshLock 回源时使用 Redis 分布式锁，集群内同一个 key 只有一个实例回源。
+// ttl 为锁的过期时间，应大于回源耗时；未获取到锁时，缓存未命中的请求最多等待 wait 后自行回源，
+// 过期刷新则直接跳过，继续使用旧值
+func WithRefreshLock(ttl time.Duration, wait time.Duration) Option {
+	return func(o *options) {
+		o.lockTTL = ttl
+		o.lockWait = wait
+	}
+}
+
+// WithInvalidator Set 与 Remove 时广播失效消息，并订阅其他实例的消息以清除一级缓存。
+// handler 可选，用于同时清除业务自身的缓存，收到的是去掉前缀与命名空间版本号的业务 key；
+// Admin.FlushPrefix 删除的 key 无法确定命名空间，保持 "ns:v1:key" 的形式
+func WithInvalidator(invalidator Invalidator, handler func(keys []string)) Option {
+	return func(o *options) {
+		o.invalidator = invalidator
+		o.invalidateHandler = handler
+	}
+}
+
+// WithKeyPrefix 所有缓存 key 统一加前缀，例如 "appname:env:"，避免共用 Redis 的服务之间 key 冲突
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.keyPrefix = prefix
+	}
+}
+
+// WithWorkerPool 配置异步写回与过期刷新的协程池，默认 32 个协程、队列长度 4096，队列满时丢弃。workers 或 queueSize <= 0 时使用默认值
+func WithWorkerPool(workers int, queueSize int, policy DropPolicy) Option {
+	return func(o *options) {
+		o.workers = workers
+		o.queueSize = queueSize
+		o.dropPolicy = policy
+	}
+}
+
+// WithAsyncTimeout 异步写回与过期刷新的超时时间，默认 5 秒，小于 0 表示不超时
+func WithAsyncTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.asyncTimeout = timeout
+	}
+}
+
+// WithVersionCacheTTL 命名空间版本号在进程内的缓存时间，默认 1 秒，<= 0 时每次读取都查询 Redis。
+// 配置了 Invalidator 时其他实例的 BumpVersion 立即生效，否则最多延迟 ttl
+func WithVersionCacheTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.versionCacheTTL = ttl
+	}
+}
+
+// WithCache 使用自定义的 Cache 实现替代 Redis，例如 NewLocalAdaptor()，此时 Init 的 rdb 可以为 nil
+func WithCache(cache Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// WithCircuitBreaker 缓存连续 threshold 次连接失败或超时后熔断 cooldown 时长，期间绕过缓存直接回源（配置了一级缓存时仍使用一级缓存），
+// 之后放行一个探测请求，成功则恢复。开启后连接失败与超时也会直接回源而不是返回错误，反序列化、解密等错误照常返回
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.breakerThreshold = threshold
+		o.breakerCooldown = cooldown
+	}
+}
+
+// WithMaxValueSize 限制写入缓存的值的最大字节数，超过时按 policy 不写入或截断，避免单个大对象占用 Redis 内存并拖慢读取。
+// 限制按值本身（StringView.Data）计算，不含条目头部与序列化、编码带来的膨胀，Redis 中实际存储的条目会更大
+func WithMaxValueSize(size int, policy OversizePolicy) Option {
+	return func(o *options) {
+		o.maxValueSize = size
+		o.oversizePolicy = policy
+	}
+}
+
+// WithWriteBehind 启用 SetBehind 写回模式，更新累计到 batchSize 个 key 或每隔 interval 批量写入数据源。
+// 默认 100 个 key、1 秒，写入失败的更新在下一批次重试
+func WithWriteBehind(writer BatchSourceWriter, batchSize int, interval time.Duration) Option {
+	return func(o *options) {
+		o.writeBehindWriter = writer
+		o.writeBehindBatch = batchSize
+		o.writeBehindInterval = interval
+	}
+}
@@ -0,0 +1,102 @@
+package cacheproxy
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+)
+
+// defaultHotKeyInterval interval <= 0 时的刷新间隔
+const defaultHotKeyInterval = time.Minute
+
+// RegisterHotKey 注册热点 key，后台立即刷新一次，之后每隔 interval 回源并写入缓存，
+// 保证请求路径上不会因该 key 过期而同步回源。interval 应小于 c.ExpiredTime，<= 0 时为 1 分钟。
+// 开启 WithRefreshLock 时集群内同一时刻只有一个实例刷新。返回的函数用于取消注册，Close 时自动停止
+func (p *CacheProxy) RegisterHotKey(c CacheContext, key string, getter SingleGetter, interval time.Duration) func() {
+	return p.RegisterHotKeys(c, func() []string { return []string{key} }, getter, interval)
+}
+
+// RegisterHotKeys 与 RegisterHotKey 相同，每次刷新时调用 keys 生成需要刷新的 key，例如当天的排行榜。
+// keys 或 getter panic 时记录日志并在下一个间隔重试，Close 之后注册不会启动刷新任务
+func (p *CacheProxy) RegisterHotKeys(c CacheContext, keys func() []string, getter SingleGetter, interval time.Duration) func() {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	if interval <= 0 {
+		interval = defaultHotKeyInterval
+	}
+	// 与 Close 互斥，避免 Close 等待 refreshWG 时新增刷新任务
+	p.refreshMu.Lock()
+	if p.refreshClosed {
+		p.refreshMu.Unlock()
+		logger.Warn("cacheProxy register hot key after close")
+		return func() {}
+	}
+	p.refreshWG.Add(1)
+	p.refreshMu.Unlock()
+	ctx, cancel := context.WithCancel(p.baseCtx)
+	go func() {
+		defer p.refreshWG.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			p.refreshHotKeys(ctx, c, keys, getter)
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.refreshStop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
+
+// refreshHotKeys 刷新一轮热点 key，recover keys 与 getter 的 panic，避免刷新任务退出
+func (p *CacheProxy) refreshHotKeys(ctx context.Context, c CacheContext, keys func() []string, getter SingleGetter) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error(fmt.Sprintf("panic in cacheProxy hot key refresh: %v, stack: %s", r, debug.Stack()))
+		}
+	}()
+	for _, key := range validKeys(keys()) {
+		if ctx.Err() != nil {
+			return
+		}
+		p.refreshHotKey(ctx, c, key, getter)
+	}
+}
+
+// refreshHotKey 回源并写入单个热点 key
+func (p *CacheProxy) refreshHotKey(ctx context.Context, c CacheContext, key string, getter SingleGetter) {
+	ctx, cancel := p.asyncContext(ctx)
+	defer cancel()
+	cacheKey, err := p.cacheKey(ctx, c, key)
+	if err != nil {
+		logger.Error("cacheProxy hot key err:" + err.Error())
+		return
+	}
+	unlock, locked := p.tryRefreshLock(ctx, cacheKey)
+	if !locked {
+		return
+	}
+	defer unlock()
+	p.recordRefresh(metrics.CacheRefreshScheduled)
+	p.hooks.refresh(ctx, false, key)
+	res, err := p.getResource(ctx, cacheKey, key, getter)
+	if isQuietErr(err) {
+		return
+	}
+	if err != nil {
+		logger.Error("cacheProxy hot key getResource err:" + err.Error())
+		return
+	}
+	if err = p.setData(ctx, c, cacheKey, res); err != nil {
+		logger.Error("cacheProxy hot key setData err:" + err.Error())
+	}
+}
@@ -0,0 +1,175 @@
+package cacheproxy
+
+import (
+	"context"
+	"hash/crc32"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+)
+
+// shardReplicas 每个分片在哈希环上的虚拟节点数
+const shardReplicas = 160
+
+// ShardedCache 按一致性哈希将 key 分布到多个独立的 Redis 实例，增减分片时只有少量 key 需要重新回源
+type ShardedCache struct {
+	hashes []uint32
+	nodes  map[uint32]*RedisCache
+	shards []*RedisCache
+}
+
+// NewShardedRedisAdaptor shards 为分片名到 Redis 客户端的映射，key 的归属由分片名决定，
+// 调整顺序或更换地址时保持名称不变即可避免数据迁移。通过 WithCache 使用，
+// 此时 New 的 rdb 仅用于命名空间版本号与回源锁，可传入任意一个分片
+func NewShardedRedisAdaptor(shards map[string]redis.UniversalClient) *ShardedCache {
+	c := &ShardedCache{nodes: make(map[uint32]*RedisCache, len(shards)*shardReplicas)}
+	names := make([]string, 0, len(shards))
+	for name := range shards {
+		names = append(names, name)
+	}
+	// 哈希冲突时按名称顺序决定归属，保证各实例路由一致
+	sort.Strings(names)
+	for _, name := range names {
+		shard := NewRedisAdaptor(shards[name])
+		c.shards = append(c.shards, shard)
+		for i := 0; i < shardReplicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(name + "#" + strconv.Itoa(i)))
+			if _, ok := c.nodes[h]; ok {
+				continue
+			}
+			c.nodes[h] = shard
+			c.hashes = append(c.hashes, h)
+		}
+	}
+	slices.Sort(c.hashes)
+	return c
+}
+
+func (c *ShardedCache) shard(key string) *RedisCache {
+	if len(c.hashes) == 0 {
+		panic("empty redis shards")
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(c.hashes), func(i int) bool { return c.hashes[i] >= h })
+	if i == len(c.hashes) {
+		i = 0
+	}
+	return c.nodes[c.hashes[i]]
+}
+
+// group 按分片分组，返回每个分片对应的 key 下标
+func (c *ShardedCache) group(keys []string) map[*RedisCache][]int {
+	groups := make(map[*RedisCache][]int)
+	for i, key := range keys {
+		shard := c.shard(key)
+		groups[shard] = append(groups[shard], i)
+	}
+	return groups
+}
+
+func (c *ShardedCache) Get(ctx context.Context, key string) (StringView, bool, error) {
+	return c.shard(key).Get(ctx, key)
+}
+
+func (c *ShardedCache) Set(ctx context.Context, key string, value StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
+	return c.shard(key).Set(ctx, key, value, expiredTime, emptyExpiredTime)
+}
+
+func (c *ShardedCache) Remove(ctx context.Context, key string) error {
+	return c.shard(key).Remove(ctx, key)
+}
+
+// MGet 各分片并发批量读取
+func (c *ShardedCache) MGet(ctx context.Context, keys []string) ([]StringView, error) {
+	res := make([]StringView, len(keys))
+	g, ctx := errgroup.WithContext(ctx)
+	for shard, idx := range c.group(keys) {
+		g.Go(func() error {
+			shardKeys := make([]string, len(idx))
+			for i, j := range idx {
+				shardKeys[i] = keys[j]
+			}
+			svs, err := shard.MGet(ctx, shardKeys)
+			if err != nil {
+				return err
+			}
+			for i, j := range idx {
+				res[j] = svs[i]
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *ShardedCache) MSet(ctx context.Context, keys []string, values []StringView, expiredTime time.Duration, emptyExpiredTime time.Duration) error {
+	if len(keys) != len(values) {
+		return ErrMismatchedPair
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	for shard, idx := range c.group(keys) {
+		g.Go(func() error {
+			shardKeys := make([]string, len(idx))
+			shardValues := make([]StringView, len(idx))
+			for i, j := range idx {
+				shardKeys[i], shardValues[i] = keys[j], values[j]
+			}
+			return shard.MSet(ctx, shardKeys, shardValues, expiredTime, emptyExpiredTime)
+		})
+	}
+	return g.Wait()
+}
+
+func (c *ShardedCache) MSetWithTTL(ctx context.Context, keys []string, values []StringView, ttls []time.Duration) error {
+	if len(keys) != len(values) || len(keys) != len(ttls) {
+		return ErrMismatchedPair
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	for shard, idx := range c.group(keys) {
+		g.Go(func() error {
+			shardKeys := make([]string, len(idx))
+			shardValues := make([]StringView, len(idx))
+			shardTTLs := make([]time.Duration, len(idx))
+			for i, j := range idx {
+				shardKeys[i], shardValues[i], shardTTLs[i] = keys[j], values[j], ttls[j]
+			}
+			return shard.MSetWithTTL(ctx, shardKeys, shardValues, shardTTLs)
+		})
+	}
+	return g.Wait()
+}
+
+// Scan 依次遍历每个分片
+func (c *ShardedCache) Scan(ctx context.Context, prefix string, batch int, fn func(keys []string) error) error {
+	for _, shard := range c.shards {
+		if err := shard.Scan(ctx, prefix, batch, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ShardedCache) MRemove(ctx context.Context, keys []string) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for shard, idx := range c.group(keys) {
+		g.Go(func() error {
+			shardKeys := make([]string, len(idx))
+			for i, j := range idx {
+				shardKeys[i] = keys[j]
+			}
+			return shard.MRemove(ctx, shardKeys)
+		})
+	}
+	return g.Wait()
+}
+
+func (c *ShardedCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return c.shard(key).TTL(ctx, key)
+}
@@ -0,0 +1,70 @@
+package cacheproxy
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+)
+
+// OversizePolicy 值超过 WithMaxValueSize 限制时的处理方式
+type OversizePolicy int
+
+const (
+	// OversizePassThrough 不写入缓存，仅返回回源结果；已有的缓存条目会被删除，避免读到旧值
+	OversizePassThrough OversizePolicy = iota
+	// OversizeTruncate 在 UTF-8 字符边界截断到不超过限制长度后写入，并标记 Truncated。
+	// 读取时通过 GetHitWithMeta 返回的 ResultMeta.Truncated 区分，GetHit 与 GetMultiHit 无法区分截断的值
+	OversizeTruncate
+)
+
+func (p OversizePolicy) String() string {
+	if p == OversizeTruncate {
+		return "truncate"
+	}
+	return "pass_through"
+}
+
+// checkSize 按大小限制处理待写入的值，返回 false 表示该值不写入缓存。只计算 Data 的长度，在序列化之前检查
+func (p *CacheProxy) checkSize(sv *StringView) bool {
+	if p.maxValueSize <= 0 || sv.Len() <= p.maxValueSize {
+		return true
+	}
+	metrics.CacheOversizeMetric(p.name, p.oversizePolicy.String())
+	if p.oversizePolicy != OversizeTruncate {
+		return false
+	}
+	end := p.maxValueSize
+	for !sv.Binary && end > 0 && !utf8.RuneStart(sv.Data[end]) {
+		end--
+	}
+	sv.Data = sv.Data[:end]
+	sv.Truncated = true
+	return true
+}
+
+// setMultiChecked 批量写入前过滤超过大小限制的值
+func (p *CacheProxy) setMultiChecked(ctx context.Context, cacheKeys []string, values []StringView, ttls []time.Duration) error {
+	if p.maxValueSize <= 0 {
+		return msetWithTTL(ctx, p.cache, cacheKeys, values, ttls)
+	}
+	keptKeys := make([]string, 0, len(cacheKeys))
+	keptValues := make([]StringView, 0, len(values))
+	keptTTLs := make([]time.Duration, 0, len(ttls))
+	for i, value := range values {
+		if !p.checkSize(&value) {
+			if err := p.cache.Remove(ctx, cacheKeys[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		keptKeys = append(keptKeys, cacheKeys[i])
+		keptValues = append(keptValues, value)
+		keptTTLs = append(keptTTLs, ttls[i])
+	}
+	if len(keptKeys) == 0 {
+		return nil
+	}
+	return msetWithTTL(ctx, p.cache, keptKeys, keptValues, keptTTLs)
+}
@@ -0,0 +1,170 @@
+package cacheproxy
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+)
+
+const (
+	// statsTopN Stats 返回的最大 key 数量
+	statsTopN = 10
+	// statsMaxTracked 采样记录的最大 key 数量
+	statsMaxTracked = 1024
+	// statsSampleRate 每多少次记录采样一次
+	statsSampleRate = 16
+)
+
+// KeyCount 未命中次数（采样）
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
+
+// KeySize 写入时的值大小（采样）
+type KeySize struct {
+	Key  string
+	Size int
+}
+
+// Stats 缓存使用情况快照，计数均为实例创建以来的累计值
+type Stats struct {
+	Hits     uint64
+	Misses   uint64
+	HitRatio float64
+	// BackSources 回源次数，批量回源计一次
+	BackSources          uint64
+	BackSourceErrors     uint64
+	AvgBackSourceLatency time.Duration
+	Refreshes            uint64
+	// QueueDepth 等待执行的后台任务数
+	QueueDepth int
+	// TopMissedKeys 未命中次数最多的 key，按采样估算
+	TopMissedKeys []KeyCount
+	// LargestKeys 写入值最大的 key，按采样估算
+	LargestKeys []KeySize
+}
+
+// statsCollector 累计计数与采样
+type statsCollector struct {
+	hits             atomic.Uint64
+	misses           atomic.Uint64
+	backSources      atomic.Uint64
+	backSourceErrors atomic.Uint64
+	backSourceNanos  atomic.Int64
+	refreshes        atomic.Uint64
+
+	mu      sync.Mutex
+	missed  map[string]uint64
+	largest map[string]int
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		missed:  make(map[string]uint64),
+		largest: make(map[string]int),
+	}
+}
+
+// Stats 返回缓存使用情况快照，用于调试接口或定期打印
+func (p *CacheProxy) Stats() Stats {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	s := p.stats
+	res := Stats{
+		Hits:             s.hits.Load(),
+		Misses:           s.misses.Load(),
+		BackSources:      s.backSources.Load(),
+		BackSourceErrors: s.backSourceErrors.Load(),
+		Refreshes:        s.refreshes.Load(),
+		QueueDepth:       len(p.pool.tasks),
+	}
+	if total := res.Hits + res.Misses; total > 0 {
+		res.HitRatio = float64(res.Hits) / float64(total)
+	}
+	if res.BackSources > 0 {
+		res.AvgBackSourceLatency = time.Duration(s.backSourceNanos.Load() / int64(res.BackSources))
+	}
+	s.mu.Lock()
+	for key, count := range s.missed {
+		res.TopMissedKeys = append(res.TopMissedKeys, KeyCount{Key: key, Count: count})
+	}
+	for key, size := range s.largest {
+		res.LargestKeys = append(res.LargestKeys, KeySize{Key: key, Size: size})
+	}
+	s.mu.Unlock()
+	sort.Slice(res.TopMissedKeys, func(i, j int) bool { return res.TopMissedKeys[i].Count > res.TopMissedKeys[j].Count })
+	sort.Slice(res.LargestKeys, func(i, j int) bool { return res.LargestKeys[i].Size > res.LargestKeys[j].Size })
+	res.TopMissedKeys = res.TopMissedKeys[:min(len(res.TopMissedKeys), statsTopN)]
+	res.LargestKeys = res.LargestKeys[:min(len(res.LargestKeys), statsTopN)]
+	return res
+}
+
+func (p *CacheProxy) recordHit(n int) {
+	metrics.CacheHitMetric(p.name, n)
+	p.stats.hits.Add(uint64(n))
+}
+
+func (p *CacheProxy) recordMiss(keys ...string) {
+	metrics.CacheMissMetric(p.name, len(keys))
+	p.stats.misses.Add(uint64(len(keys)))
+	if rand.IntN(statsSampleRate) != 0 {
+		return
+	}
+	s := p.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if _, ok := s.missed[key]; ok || len(s.missed) < statsMaxTracked {
+			s.missed[key]++
+		}
+	}
+}
+
+func (p *CacheProxy) recordBackSource(elapsed time.Duration, err error) {
+	// 不可缓存的结果说明数据源可用
+	if errors.Is(err, ErrNotCacheable) {
+		err = nil
+	}
+	metrics.CacheBackSourceMetric(p.name, elapsed, err)
+	p.stats.backSources.Add(1)
+	p.stats.backSourceNanos.Add(int64(elapsed))
+	if err != nil {
+		p.stats.backSourceErrors.Add(1)
+	}
+}
+
+func (p *CacheProxy) recordRefresh(refreshType string) {
+	metrics.CacheRefreshMetric(p.name, refreshType)
+	p.stats.refreshes.Add(1)
+}
+
+// recordSize 采样记录写入值的大小，已满时替换当前最小的 key
+func (p *CacheProxy) recordSize(cacheKey string, size int) {
+	if size == 0 || rand.IntN(statsSampleRate) != 0 {
+		return
+	}
+	s := p.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.largest[cacheKey]; ok || len(s.largest) < statsMaxTracked {
+		s.largest[cacheKey] = size
+		return
+	}
+	minKey, minSize := "", size
+	for key, v := range s.largest {
+		if v < minSize {
+			minKey, minSize = key, v
+		}
+	}
+	if minKey != "" {
+		delete(s.largest, minKey)
+		s.largest[cacheKey] = size
+	}
+}
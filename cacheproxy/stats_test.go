@@ -0,0 +1,42 @@
+package cacheproxy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStatsHitAndMiss(t *testing.T) {
+	p := New(nil, WithCache(NewLocalAdaptor()), WithName("stats_test"))
+	defer p.Close(context.Background())
+	ctx := context.Background()
+	c := CacheContext{ExpiredTime: time.Minute, EmptyExpiredTime: time.Minute}
+	getter := SingleGetterFunc(func(ctx context.Context, key string) (string, bool, error) {
+		return "value", false, nil
+	})
+
+	data, hit, err := p.GetHit(ctx, c, "key", getter)
+	if err != nil || hit || data != "value" {
+		t.Fatalf("first GetHit = %q, %v, %v, want miss", data, hit, err)
+	}
+	// 未命中后异步写入缓存
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, exist, _ := p.cache.Get(ctx, "key"); exist {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("value not written to cache")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	data, hit, err = p.GetHit(ctx, c, "key", getter)
+	if err != nil || !hit || data != "value" {
+		t.Fatalf("second GetHit = %q, %v, %v, want hit", data, hit, err)
+	}
+
+	stats := p.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.BackSources != 1 {
+		t.Fatalf("stats = %+v, want 1 hit, 1 miss, 1 back source", stats)
+	}
+}
@@ -1,12 +1,24 @@
 package cacheproxy
 
-import "time"
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
 
 type StringView struct {
 	Ctime           time.Time `json:"ctime"`
 	NeedFastRequery bool      `json:"need_fast_requery"`
 	IsNil           bool      `json:"is_nil"`
 	Data            string    `json:"data"`
+	// Delta 回源耗时，XFetch 提前刷新时使用
+	Delta time.Duration `json:"delta,omitempty"`
+	// Truncated 值超过大小限制被截断
+	Truncated bool `json:"truncated,omitempty"`
+	// Encrypted Data 为 WithEncryption 加密后的密文
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Binary Data 为二进制数据，Redis 中以二进制格式存储
+	Binary bool `json:"binary,omitempty"`
 }
 
 func (v StringView) IsExpire(normalOffset time.Duration, fastOffset time.Duration) bool {
@@ -23,6 +35,21 @@ func (v StringView) IsExpire(normalOffset time.Duration, fastOffset time.Duratio
 	return false
 }
 
+// IsEarlyExpire XFetch 算法：回源越慢、越接近刷新时间，越可能提前判定为过期，
+// 使热点 key 由单个请求提前刷新而不是到期时集中回源。beta 越大越倾向提前刷新
+func (v StringView) IsEarlyExpire(normalOffset time.Duration, fastOffset time.Duration, beta float64) bool {
+	if v.Ctime.IsZero() {
+		return false
+	}
+	offset := normalOffset
+	if v.NeedFastRequery {
+		offset = fastOffset
+	}
+	// 1-rand 取值 (0, 1]，避免 log(0)
+	gap := time.Duration(float64(v.Delta) * beta * -math.Log(1-rand.Float64()))
+	return !time.Now().Add(gap).Before(v.Ctime.Add(offset))
+}
+
 func (v StringView) Len() int {
 	return len(v.Data)
 }
@@ -0,0 +1,159 @@
+package cacheproxy
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	versionKeyPrefix = "cacheproxy:version:"
+	// defaultVersionCacheTTL 命名空间版本号在进程内的缓存时间
+	defaultVersionCacheTTL = time.Second
+)
+
+// versionStore 命名空间版本号存储
+type versionStore interface {
+	Get(ctx context.Context, ns string) (int64, error)
+	Incr(ctx context.Context, ns string) (int64, error)
+}
+
+type redisVersionStore struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+func (s *redisVersionStore) Get(ctx context.Context, ns string) (int64, error) {
+	version, err := s.rdb.Get(ctx, s.prefix+versionKeyPrefix+ns).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+func (s *redisVersionStore) Incr(ctx context.Context, ns string) (int64, error) {
+	return s.rdb.Incr(ctx, s.prefix+versionKeyPrefix+ns).Result()
+}
+
+// localVersionStore 未使用 Redis 时在进程内保存版本号
+type localVersionStore struct {
+	mu       sync.Mutex
+	versions map[string]int64
+}
+
+func (s *localVersionStore) Get(ctx context.Context, ns string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions[ns], nil
+}
+
+func (s *localVersionStore) Incr(ctx context.Context, ns string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[ns]++
+	return s.versions[ns], nil
+}
+
+// cachedVersionStore 在进程内缓存版本号 ttl 时长，避免每次读取都访问 Redis。
+// 其他实例 BumpVersion 后通过 Invalidator 广播立即失效，未配置时最多延迟 ttl 生效
+type cachedVersionStore struct {
+	inner versionStore
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	versions map[string]cachedVersion
+}
+
+type cachedVersion struct {
+	version  int64
+	expireAt time.Time
+}
+
+func newCachedVersionStore(inner versionStore, ttl time.Duration) versionStore {
+	if ttl <= 0 {
+		return inner
+	}
+	return &cachedVersionStore{inner: inner, ttl: ttl, versions: make(map[string]cachedVersion)}
+}
+
+func (s *cachedVersionStore) Get(ctx context.Context, ns string) (int64, error) {
+	s.mu.Lock()
+	v, ok := s.versions[ns]
+	s.mu.Unlock()
+	if ok && time.Now().Before(v.expireAt) {
+		return v.version, nil
+	}
+	version, err := s.inner.Get(ctx, ns)
+	if err != nil {
+		return 0, err
+	}
+	s.store(ns, version)
+	return version, nil
+}
+
+func (s *cachedVersionStore) Incr(ctx context.Context, ns string) (int64, error) {
+	version, err := s.inner.Incr(ctx, ns)
+	if err != nil {
+		return 0, err
+	}
+	s.store(ns, version)
+	return version, nil
+}
+
+// forget 清除进程内缓存的版本号，下次读取时重新查询
+func (s *cachedVersionStore) forget(namespaces ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ns := range namespaces {
+		delete(s.versions, ns)
+	}
+}
+
+func (s *cachedVersionStore) store(ns string, version int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 并发读取与 Incr 时不回退到旧版本
+	if v, ok := s.versions[ns]; ok && v.version > version {
+		return
+	}
+	s.versions[ns] = cachedVersion{version: version, expireAt: time.Now().Add(s.ttl)}
+}
+
+// BumpVersion 递增命名空间版本号，使用该命名空间的所有缓存立即失效，旧 key 随过期时间自然淘汰。
+// 其他实例缓存的版本号通过 Invalidator 广播失效，未配置 Invalidator 时最多延迟 WithVersionCacheTTL 生效。
+// New 的 rdb 为 nil 时版本号只保存在进程内，BumpVersion 只对当前进程生效
+func (p *CacheProxy) BumpVersion(ctx context.Context, ns string) (int64, error) {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	version, err := p.versions.Incr(ctx, ns)
+	if err != nil {
+		return 0, err
+	}
+	if p.invalidator != nil {
+		err = p.invalidator.Publish(ctx, InvalidateMessage{Source: p.instanceID, Namespaces: []string{ns}})
+		if err != nil {
+			logger.Error("cacheProxy publish version invalidate err:" + err.Error())
+		}
+	}
+	return version, nil
+}
+
+// forgetVersions 收到其他实例 BumpVersion 的广播，清除进程内缓存的版本号
+func (p *CacheProxy) forgetVersions(namespaces []string) {
+	if s, ok := p.versions.(*cachedVersionStore); ok {
+		s.forget(namespaces...)
+	}
+}
+
+func versionedKey(ns string, version int64, key string) string {
+	return ns + ":v" + strconv.FormatInt(version, 10) + ":" + key
+}
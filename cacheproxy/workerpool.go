@@ -0,0 +1,187 @@
+package cacheproxy
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+)
+
+// DropPolicy 队列已满时的处理策略
+type DropPolicy int
+
+const (
+	// DropPolicyDiscard 丢弃任务
+	DropPolicyDiscard DropPolicy = iota
+	// DropPolicyCallerRuns 在调用方 goroutine 中同步执行
+	DropPolicyCallerRuns
+	// DropPolicyBlock 阻塞等待队列空闲
+	DropPolicyBlock
+)
+
+const (
+	defaultWorkers   = 32
+	defaultQueueSize = 4096
+)
+
+// workerPool 固定数量的后台协程，执行缓存异步写回与过期刷新
+type workerPool struct {
+	name   string
+	tasks  chan func()
+	policy DropPolicy
+	wg     sync.WaitGroup
+
+	// mu 保护 closed，关闭后不再接受新任务
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newWorkerPool(name string, workers int, queueSize int, policy DropPolicy) *workerPool {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	w := &workerPool{
+		name:   name,
+		tasks:  make(chan func(), queueSize),
+		policy: policy,
+	}
+	w.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go w.run()
+	}
+	return w
+}
+
+// Submit 提交任务，任务被丢弃或协程池已关闭时返回 false
+func (w *workerPool) Submit(task func()) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		metrics.CacheAsyncTaskMetric(w.name, metrics.CacheAsyncDropped)
+		return false
+	}
+	switch w.policy {
+	case DropPolicyBlock:
+		w.tasks <- task
+	default:
+		select {
+		case w.tasks <- task:
+		default:
+			if w.policy == DropPolicyCallerRuns {
+				metrics.CacheAsyncTaskMetric(w.name, metrics.CacheAsyncCallerRuns)
+				w.execute(task)
+				return true
+			}
+			metrics.CacheAsyncTaskMetric(w.name, metrics.CacheAsyncDropped)
+			return false
+		}
+	}
+	metrics.CacheAsyncTaskMetric(w.name, metrics.CacheAsyncSubmitted)
+	metrics.CacheAsyncQueueMetric(w.name, len(w.tasks))
+	return true
+}
+
+func (w *workerPool) run() {
+	defer w.wg.Done()
+	for task := range w.tasks {
+		metrics.CacheAsyncQueueMetric(w.name, len(w.tasks))
+		w.execute(task)
+	}
+}
+
+func (w *workerPool) execute(task func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error(fmt.Sprintf("panic in cacheProxy async task: %v, stack: %s", r, debug.Stack()))
+		}
+	}()
+	task()
+}
+
+// close 停止接受新任务，等待队列中与执行中的任务完成，ctx 结束时不再等待
+func (w *workerPool) close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.tasks)
+	}
+	w.mu.Unlock()
+	return waitContext(ctx, &w.wg)
+}
+
+// waitContext 等待 wg 完成，ctx 结束时不再等待并返回 ctx 的错误
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// async 提交后台任务，任务被丢弃时返回 false。
+// 任务执行时的 ctx 带有 WithAsyncTimeout 超时，并在 Close 超时后取消
+func (p *CacheProxy) async(task func(ctx context.Context)) bool {
+	return p.pool.Submit(func() {
+		ctx, cancel := p.asyncContext(p.baseCtx)
+		defer cancel()
+		task(ctx)
+	})
+}
+
+// asyncContext 为后台任务附加 WithAsyncTimeout 超时
+func (p *CacheProxy) asyncContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if p.asyncTimeout > 0 {
+		return context.WithTimeout(parent, p.asyncTimeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// Close 停止后台任务并释放资源：停止热点 key 刷新，不再接受新的异步写入，等待执行中的任务完成，写入写回队列中剩余的更新，并取消失效消息订阅。
+// ctx 结束时取消仍在执行的后台任务并返回 ctx 的错误。重复调用时直接返回 nil
+func (p *CacheProxy) Close(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	var err error
+	p.closeOnce.Do(func() {
+		defer p.cancel()
+		if p.unsubscribe != nil {
+			p.unsubscribe()
+		}
+		if p.unregisterPoolMetrics != nil {
+			p.unregisterPoolMetrics()
+		}
+		// 停止热点 key 刷新，执行中的刷新受 WithAsyncTimeout 限制，ctx 结束时取消
+		p.refreshMu.Lock()
+		p.refreshClosed = true
+		close(p.refreshStop)
+		p.refreshMu.Unlock()
+		if err = waitContext(ctx, &p.refreshWG); err != nil {
+			p.cancel()
+		}
+		if poolErr := p.pool.close(ctx); poolErr != nil {
+			p.cancel()
+			if err == nil {
+				err = poolErr
+			}
+		}
+		if p.writeBehind != nil {
+			if flushErr := p.writeBehind.close(ctx); flushErr != nil && err == nil {
+				err = flushErr
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,190 @@
+package cacheproxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+)
+
+var ErrWriteBehindDisabled = errors.New("write-behind not configured")
+
+const (
+	defaultWriteBehindBatch    = 100
+	defaultWriteBehindInterval = time.Second
+)
+
+// SourceWriter 写穿模式下更新数据源
+type SourceWriter interface {
+	Set(ctx context.Context, key string, value string) error
+}
+
+type SourceWriterFunc func(ctx context.Context, key string, value string) error
+
+func (f SourceWriterFunc) Set(ctx context.Context, key string, value string) error {
+	return f(ctx, key, value)
+}
+
+// BatchSourceWriter 写回模式下批量更新数据源，data 为业务 key 到最新值的映射
+type BatchSourceWriter interface {
+	MSet(ctx context.Context, data map[string]string) error
+}
+
+type BatchSourceWriterFunc func(ctx context.Context, data map[string]string) error
+
+func (f BatchSourceWriterFunc) MSet(ctx context.Context, data map[string]string) error {
+	return f(ctx, data)
+}
+
+// SetThrough 写穿：先更新数据源，成功后写入缓存并广播失效。
+// 数据源更新失败时缓存保持不变；缓存写入失败时删除该 key，避免读到旧值
+func (p *CacheProxy) SetThrough(ctx context.Context, c CacheContext, key string, value string, writer SourceWriter) error {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	cacheKey, err := p.cacheKey(ctx, c, key)
+	if err != nil {
+		return err
+	}
+	if err = writer.Set(ctx, key, value); err != nil {
+		return err
+	}
+	if err = p.setData(ctx, c, cacheKey, resource{data: value}); err != nil {
+		if rmErr := p.cache.Remove(ctx, cacheKey); rmErr != nil {
+			logger.Error("cacheProxy setThrough remove err:" + rmErr.Error())
+		}
+		p.publishInvalidate(ctx, c.Namespace, cacheKey)
+		return err
+	}
+	p.publishInvalidate(ctx, c.Namespace, cacheKey)
+	return nil
+}
+
+// SetBehind 写回：立即写入缓存，数据源的更新进入队列，由后台按批次合并写入。
+// 需通过 WithWriteBehind 配置，同一个 key 在一个批次内只写入最新值
+func (p *CacheProxy) SetBehind(ctx context.Context, c CacheContext, key string, value string) error {
+	if p == nil {
+		panic("empty cacheProxy")
+	}
+	if p.writeBehind == nil {
+		return ErrWriteBehindDisabled
+	}
+	if err := p.Set(ctx, c, key, value); err != nil {
+		return err
+	}
+	p.writeBehind.add(key, value)
+	return nil
+}
+
+// FlushWriteBehind 立即将写回队列中的更新写入数据源
+func (p *CacheProxy) FlushWriteBehind(ctx context.Context) error {
+	if p.writeBehind == nil {
+		return ErrWriteBehindDisabled
+	}
+	return p.writeBehind.flush(ctx)
+}
+
+// writeBehindQueue 合并待写入数据源的更新，按批次大小或时间间隔写入
+type writeBehindQueue struct {
+	name      string
+	writer    BatchSourceWriter
+	batchSize int
+
+	mu      sync.Mutex
+	pending map[string]string
+	// flushMu 保证同一时间只有一个批次在写入，失败重新入队时不会覆盖更新的值
+	flushMu sync.Mutex
+	notify  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func newWriteBehindQueue(name string, writer BatchSourceWriter, batchSize int, interval time.Duration) *writeBehindQueue {
+	if batchSize <= 0 {
+		batchSize = defaultWriteBehindBatch
+	}
+	if interval <= 0 {
+		interval = defaultWriteBehindInterval
+	}
+	q := &writeBehindQueue{
+		name:      name,
+		writer:    writer,
+		batchSize: batchSize,
+		pending:   make(map[string]string),
+		notify:    make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go q.run(interval)
+	return q
+}
+
+func (q *writeBehindQueue) add(key string, value string) {
+	q.mu.Lock()
+	q.pending[key] = value
+	full := len(q.pending) >= q.batchSize
+	q.mu.Unlock()
+	if full {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (q *writeBehindQueue) run(interval time.Duration) {
+	defer close(q.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+		case <-q.notify:
+		}
+		if err := q.flush(context.Background()); err != nil {
+			logger.Error("cacheProxy write-behind flush err:" + err.Error())
+		}
+	}
+}
+
+// flush 写入当前队列中的全部更新，失败的批次重新入队，下次写入时重试
+func (q *writeBehindQueue) flush(ctx context.Context) error {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+	q.mu.Lock()
+	if len(q.pending) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	batch := q.pending
+	q.pending = make(map[string]string)
+	q.mu.Unlock()
+
+	err := q.writer.MSet(ctx, batch)
+	if err == nil {
+		metrics.CacheWriteBehindMetric(q.name, metrics.CacheWriteBehindFlushed, len(batch))
+		return nil
+	}
+	metrics.CacheWriteBehindMetric(q.name, metrics.CacheWriteBehindFailed, len(batch))
+	q.mu.Lock()
+	for key, value := range batch {
+		// 入队期间已有更新的值时保留新值
+		if _, ok := q.pending[key]; !ok {
+			q.pending[key] = value
+		}
+	}
+	q.mu.Unlock()
+	return err
+}
+
+// close 停止后台写入，并写入剩余的更新
+func (q *writeBehindQueue) close(ctx context.Context) error {
+	close(q.stop)
+	<-q.done
+	return q.flush(ctx)
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultBatchConcurrency = 8
+
+// BatchRequest DoBatch 中的一个 JSON 请求
+type BatchRequest struct {
+	// Method 默认 GET
+	Method  string
+	URL     string
+	Headers map[string]string
+	// Body 以 JSON 发送的请求体，为 nil 时不发送
+	Body any
+	// Resp 响应的解码目标，为 nil 时不解码
+	Resp any
+}
+
+// BatchResponse 与 BatchRequest 一一对应的结果
+type BatchResponse struct {
+	// StatusCode 未收到响应时为 0
+	StatusCode int
+	Err        error
+	Latency    time.Duration
+}
+
+// BatchResult DoBatch 的结果，Responses 与请求顺序一致
+type BatchResult struct {
+	Responses []BatchResponse
+	// Failed Err 不为 nil 的请求数
+	Failed int
+	// Elapsed 整批请求的耗时
+	Elapsed time.Duration
+}
+
+// DoBatch 以最多 concurrency 个并发（<= 0 时为 8）执行一批 JSON 请求，单个请求失败不影响其他请求。
+// ctx 结束时尚未开始的请求直接返回 ctx 的错误。每个请求的日志、重试与其他方法一致
+func (c *DalHttpClient) DoBatch(ctx context.Context, requests []BatchRequest, concurrency int, opts ...CallOption) BatchResult {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	start := time.Now()
+	res := BatchResult{Responses: make([]BatchResponse, len(requests))}
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, req := range requests {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				res.Responses[i] = BatchResponse{Err: err}
+				return nil
+			}
+			method := req.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+			reqStart := time.Now()
+			rawResponse, err := c.callJson(ctx, "DoBatch", method, req.URL, req.Headers, req.Body, opts)
+			if err == nil {
+				err = decodeJson(rawResponse, req.Resp)
+			}
+			res.Responses[i] = BatchResponse{StatusCode: rawResponse.status, Err: err, Latency: time.Since(reqStart)}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	for _, r := range res.Responses {
+		if r.Err != nil {
+			res.Failed++
+		}
+	}
+	res.Elapsed = time.Since(start)
+	return res
+}
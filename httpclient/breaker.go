@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+	"go.uber.org/zap"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+const defaultBreakerCooldown = 10 * time.Second
+
+// BreakerConfig 按 host 熔断：连续失败 Threshold 次后熔断 Cooldown 时长，期间请求直接返回 ErrCircuitOpen，
+// 之后放行一个探测请求，成功则恢复。网络错误与 5xx 计为失败
+type BreakerConfig struct {
+	// Threshold 连续失败次数，<= 0 时不启用
+	Threshold int
+	// Cooldown 熔断时长，默认 10s
+	Cooldown time.Duration
+}
+
+type hostBreaker struct {
+	state    string
+	failures int
+	openedAt time.Time
+	// probing 半开状态下已有探测请求在执行
+	probing bool
+}
+
+// breakerGroup 每个 host 一个熔断器
+type breakerGroup struct {
+	conf   BreakerConfig
+	dalLog *zap.Logger
+
+	mu    sync.Mutex
+	hosts map[string]*hostBreaker
+}
+
+func newBreakerGroup(conf BreakerConfig, dalLog *zap.Logger) *breakerGroup {
+	if conf.Threshold <= 0 {
+		return nil
+	}
+	if conf.Cooldown <= 0 {
+		conf.Cooldown = defaultBreakerCooldown
+	}
+	return &breakerGroup{conf: conf, dalLog: dalLog, hosts: make(map[string]*hostBreaker)}
+}
+
+// allow 是否放行请求，未启用熔断时总是放行
+func (g *breakerGroup) allow(host string) bool {
+	if g == nil {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b := g.host(host)
+	switch b.state {
+	case metrics.BreakerOpen:
+		if time.Since(b.openedAt) < g.conf.Cooldown {
+			return false
+		}
+		g.transition(host, b, metrics.BreakerHalfOpen)
+		b.probing = true
+		return true
+	case metrics.BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// done 记录请求结果
+func (g *breakerGroup) done(host string, failed bool) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b := g.host(host)
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		if b.state != metrics.BreakerClosed {
+			g.transition(host, b, metrics.BreakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == metrics.BreakerHalfOpen || (b.state == metrics.BreakerClosed && b.failures >= g.conf.Threshold) {
+		b.openedAt = time.Now()
+		g.transition(host, b, metrics.BreakerOpen)
+	}
+}
+
+// release 调用方取消的请求不能说明下游是否可用，只释放探测名额，不记录成功或失败
+func (g *breakerGroup) release(host string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.host(host).probing = false
+}
+
+func (g *breakerGroup) host(host string) *hostBreaker {
+	b, ok := g.hosts[host]
+	if !ok {
+		b = &hostBreaker{state: metrics.BreakerClosed}
+		g.hosts[host] = b
+	}
+	return b
+}
+
+func (g *breakerGroup) transition(host string, b *hostBreaker, state string) {
+	g.dalLog.Warn("circuit breaker state changed",
+		zap.String("host", host),
+		zap.String("from", b.state),
+		zap.String("to", state),
+		zap.Int("failures", b.failures),
+	)
+	b.state = state
+	metrics.HttpClientBreakerMetric(host, state)
+}
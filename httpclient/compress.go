@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithRequestGzip 请求体不小于 minSize 字节时以 gzip 压缩发送并设置 Content-Encoding，
+// 已设置 Content-Encoding 的请求与流式请求体不压缩。dal 日志仍记录压缩前的请求体
+func WithRequestGzip(minSize int) Option {
+	return func(o *options) {
+		o.gzipMinSize = minSize
+	}
+}
+
+// compressRequest 按 WithRequestGzip 压缩请求体，压缩失败时按原样发送
+func (c *DalHttpClient) compressRequest(r dalRequest) dalRequest {
+	if c.opts.gzipMinSize <= 0 || r.stream != nil || len(r.body) < c.opts.gzipMinSize {
+		return r
+	}
+	for k := range r.headers {
+		if http.CanonicalHeaderKey(k) == "Content-Encoding" {
+			return r
+		}
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(r.body); err != nil {
+		return r
+	}
+	if err := zw.Close(); err != nil {
+		return r
+	}
+	if r.logFields == nil {
+		r.logFields = []zapcore.Field{zap.ByteString("data", r.body)}
+	}
+	r.body = buf.Bytes()
+	r.headers = withHeader(r.headers, "Content-Encoding", "gzip")
+	return r
+}
+
+// decodeBody 按 Content-Encoding 解压响应体。Transport 自动解压时会移除该响应头，
+// 因此只在关闭自动解压或调用方自行设置 Accept-Encoding 时生效
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		return zlib.NewReader(resp.Body)
+	default:
+		return resp.Body, nil
+	}
+}
@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultDNSTTL         = time.Minute
+	defaultDNSNegativeTTL = 5 * time.Second
+	defaultDNSStaleTTL    = 10 * time.Minute
+	dnsLookupTimeout      = 10 * time.Second
+	// dnsCleanupSize 缓存的域名数量超过该值时清理长时间未刷新的记录
+	dnsCleanupSize = 1000
+)
+
+// DNSCacheConfig DNS 缓存配置
+type DNSCacheConfig struct {
+	// TTL 解析结果的缓存时长，默认 1 分钟。过期后先返回旧结果并在后台刷新
+	TTL time.Duration
+	// NegativeTTL 解析失败的缓存时长，默认 5s
+	NegativeTTL time.Duration
+	// StaleTTL 过期后仍可使用旧结果的时长，刷新失败时继续使用，默认 10 分钟
+	StaleTTL time.Duration
+	// Resolver 默认 net.DefaultResolver
+	Resolver *net.Resolver
+}
+
+// WithDNSCache 缓存域名解析结果，建连时按顺序尝试解析出的地址。WithTransport 替换 Transport 时不生效
+func WithDNSCache(conf DNSCacheConfig) Option {
+	return func(o *options) {
+		if conf.TTL <= 0 {
+			conf.TTL = defaultDNSTTL
+		}
+		if conf.NegativeTTL <= 0 {
+			conf.NegativeTTL = defaultDNSNegativeTTL
+		}
+		if conf.StaleTTL <= 0 {
+			conf.StaleTTL = defaultDNSStaleTTL
+		}
+		if conf.Resolver == nil {
+			conf.Resolver = net.DefaultResolver
+		}
+		o.dnsCache = &conf
+	}
+}
+
+type dnsEntry struct {
+	addrs      []string
+	err        error
+	resolvedAt time.Time
+	refreshing bool
+}
+
+// dnsCache 带过期刷新与失败缓存的域名解析
+type dnsCache struct {
+	conf  DNSCacheConfig
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+func newDNSCache(conf DNSCacheConfig) *dnsCache {
+	return &dnsCache{conf: conf, entries: make(map[string]*dnsEntry)}
+}
+
+// lookup 返回 host 的地址。过期但仍在 StaleTTL 内时返回旧结果并异步刷新
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+	d.mu.Lock()
+	e, ok := d.entries[host]
+	if ok {
+		age := now.Sub(e.resolvedAt)
+		switch {
+		case e.err != nil && age < d.conf.NegativeTTL:
+			d.mu.Unlock()
+			return nil, e.err
+		case e.err == nil && age < d.conf.TTL:
+			d.mu.Unlock()
+			return e.addrs, nil
+		case e.err == nil && age < d.conf.TTL+d.conf.StaleTTL:
+			if !e.refreshing {
+				e.refreshing = true
+				go d.refresh(host)
+			}
+			d.mu.Unlock()
+			return e.addrs, nil
+		}
+	}
+	d.mu.Unlock()
+
+	// 并发请求共享一次解析，解析不随第一个调用方取消，每个调用方只按自己的 ctx 等待
+	ch := d.group.DoChan(host, func() (any, error) {
+		lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), dnsLookupTimeout)
+		defer cancel()
+		return d.resolve(lookupCtx, host)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]string), nil
+	}
+}
+
+func (d *dnsCache) refresh(host string) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+	_, _, _ = d.group.Do(host, func() (any, error) {
+		return d.resolve(ctx, host)
+	})
+}
+
+// resolve 解析并更新缓存。已有可用的旧结果时解析失败不覆盖旧结果
+func (d *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	addrs, err := d.conf.Resolver.LookupHost(ctx, host)
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.entries) >= dnsCleanupSize {
+		for k, e := range d.entries {
+			if now.Sub(e.resolvedAt) > d.conf.TTL+d.conf.StaleTTL {
+				delete(d.entries, k)
+			}
+		}
+	}
+	e, ok := d.entries[host]
+	if err != nil {
+		if ok && e.err == nil && now.Sub(e.resolvedAt) < d.conf.TTL+d.conf.StaleTTL {
+			e.refreshing = false
+			return e.addrs, nil
+		}
+		// 解析超时不缓存
+		if ctx.Err() == nil {
+			d.entries[host] = &dnsEntry{err: err, resolvedAt: now}
+		}
+		return nil, err
+	}
+	d.entries[host] = &dnsEntry{addrs: addrs, resolvedAt: now}
+	return addrs, nil
+}
+
+// dialContext 解析 addr 中的域名后按顺序连接各个地址，返回最后一次连接错误
+func (d *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network string, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		ips, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			var conn net.Conn
+			conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, err
+	}
+}
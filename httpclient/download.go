@@ -0,0 +1,251 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrChecksumMismatch = errors.New("download checksum mismatch")
+
+// ErrRangeNotSupported 服务端未按 Range 返回部分内容
+var ErrRangeNotSupported = errors.New("range request not supported")
+
+// ErrResourceChanged 续传时资源的 ETag 或 Last-Modified 与首次响应不一致，已写入的内容不可用
+var ErrResourceChanged = errors.New("resource changed during download")
+
+// errStreamFailed 响应体已开始写入 writer 后失败，不能重试
+var errStreamFailed = errors.New("failed to stream response body")
+
+// download 流式写出 2xx 响应体，记录已写入的字节数与首次响应的校验信息用于续传
+type download struct {
+	w        io.Writer
+	progress func(written int64, total int64)
+	hash     hash.Hash
+	expected string
+
+	rangeStart int64
+	// rangeEnd 小于 0 表示到文件末尾
+	rangeEnd int64
+	hasRange bool
+	// offset 已写入 w 的字节数
+	offset int64
+	// total 首次响应的 Content-Length
+	total        int64
+	etag         string
+	lastModified string
+	// readFailed 上次失败是读取响应体出错，而不是写入 w 出错
+	readFailed bool
+}
+
+// WithProgress 每次写入后回调已写入字节数，total 为响应的 Content-Length，未知时为 -1
+func WithProgress(progress func(written int64, total int64)) CallOption {
+	return func(o *callOptions) {
+		o.progress = progress
+	}
+}
+
+// WithChecksum 下载完成后用 h 校验内容，expected 为十六进制摘要（不区分大小写），不一致时返回 ErrChecksumMismatch。
+// 校验在写入完成后进行，不一致时 writer 中已写入全部内容
+func WithChecksum(h hash.Hash, expected string) CallOption {
+	return func(o *callOptions) {
+		o.hash = h
+		o.expected = expected
+	}
+}
+
+// WithRange 只下载 [start, end] 字节，end 小于 0 表示到文件末尾。服务端未返回 206 时返回 ErrRangeNotSupported
+func WithRange(start int64, end int64) CallOption {
+	return func(o *callOptions) {
+		o.rangeStart = start
+		o.rangeEnd = end
+		o.hasRange = true
+	}
+}
+
+// WithResume 读取响应体中断时最多续传 maxResumes 次，用 Range 从已写入的位置继续下载，间隔按 Retry 配置退避。
+// 续传要求首次响应带有 ETag 或 Last-Modified，续传响应与其不一致时返回 ErrResourceChanged
+func WithResume(maxResumes int) CallOption {
+	return func(o *callOptions) {
+		o.maxResumes = maxResumes
+	}
+}
+
+// Download 发送 GET 请求并将响应体流式写入 w，不受 10MB 响应体上限限制。
+// 只在写入 w 之前重试，写入后中断时可用 WithResume 续传；非 2xx 响应返回 *HTTPError
+func (c *DalHttpClient) Download(ctx context.Context, url string, headers map[string]string, w io.Writer, opts ...CallOption) error {
+	o := newCallOptions(opts)
+	d := &download{
+		w:          w,
+		progress:   o.progress,
+		hash:       o.hash,
+		expected:   o.expected,
+		rangeStart: o.rangeStart,
+		rangeEnd:   o.rangeEnd,
+		hasRange:   o.hasRange,
+	}
+	for resumes := 0; ; resumes++ {
+		rawResponse, err := c.do(ctx, dalRequest{
+			name:     "Download",
+			method:   http.MethodGet,
+			url:      url,
+			headers:  d.rangeHeaders(headers),
+			timeout:  c.callTimeout(o),
+			download: d,
+		})
+		if err != nil {
+			if resumes >= o.maxResumes || !d.resumable(err) {
+				return err
+			}
+			if sleepErr := sleepContext(ctx, c.retry.backoff(resumes)); sleepErr != nil {
+				return err
+			}
+			continue
+		}
+		if !isSuccess(rawResponse.status) {
+			return newHTTPError(rawResponse)
+		}
+		return nil
+	}
+}
+
+// rangeHeaders 按已写入的字节数设置 Range，续传时用 If-Range 要求资源未变化
+func (d *download) rangeHeaders(headers map[string]string) map[string]string {
+	if !d.hasRange && d.offset == 0 {
+		return headers
+	}
+	value := fmt.Sprintf("bytes=%d-", d.rangeStart+d.offset)
+	if d.rangeEnd >= 0 && d.hasRange {
+		value += strconv.FormatInt(d.rangeEnd, 10)
+	}
+	headers = withHeader(headers, "Range", value)
+	if d.offset > 0 {
+		// If-Range 不接受弱 ETag
+		if d.etag != "" && !strings.HasPrefix(d.etag, "W/") {
+			headers = withHeader(headers, "If-Range", d.etag)
+		} else if d.lastModified != "" {
+			headers = withHeader(headers, "If-Range", d.lastModified)
+		}
+	}
+	return headers
+}
+
+// resumable 读取响应体中断且有校验信息时可以续传
+func (d *download) resumable(err error) bool {
+	return errors.Is(err, errStreamFailed) && d.readFailed && (d.etag != "" || d.lastModified != "")
+}
+
+// check 写入前校验响应，续传时要求内容从已写入的位置开始且资源未变化
+func (d *download) check(resp *http.Response) error {
+	if !d.hasRange && d.offset == 0 {
+		return nil
+	}
+	errMismatch := ErrRangeNotSupported
+	if d.offset > 0 {
+		errMismatch = ErrResourceChanged
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("%w: status %d", errMismatch, resp.StatusCode)
+	}
+	expectedStart := d.rangeStart + d.offset
+	if start, ok := parseContentRangeStart(resp.Header.Get("Content-Range")); !ok || start != expectedStart {
+		return fmt.Errorf("%w: expected content from byte %d, got Content-Range %q", errMismatch, expectedStart, resp.Header.Get("Content-Range"))
+	}
+	if d.offset == 0 {
+		return nil
+	}
+	if etag := resp.Header.Get("ETag"); d.etag != "" && etag != d.etag {
+		return fmt.Errorf("%w: ETag %s, was %s", ErrResourceChanged, etag, d.etag)
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); d.lastModified != "" && lastModified != d.lastModified {
+		return fmt.Errorf("%w: Last-Modified %s, was %s", ErrResourceChanged, lastModified, d.lastModified)
+	}
+	return nil
+}
+
+// parseContentRangeStart 解析 "bytes start-end/size" 中的 start
+func parseContentRangeStart(contentRange string) (int64, bool) {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	return n, err == nil
+}
+
+// copy 写出响应体并校验，返回本次写入的字节数。续传时接着上次的摘要与进度继续写入
+func (d *download) copy(resp *http.Response) (int64, error) {
+	if err := d.check(resp); err != nil {
+		return 0, err
+	}
+	if d.offset == 0 {
+		d.total = resp.ContentLength
+		d.etag = resp.Header.Get("ETag")
+		d.lastModified = resp.Header.Get("Last-Modified")
+	}
+	w := d.w
+	if d.hash != nil {
+		if d.offset == 0 {
+			d.hash.Reset()
+		}
+		w = io.MultiWriter(w, d.hash)
+	}
+	if d.progress != nil {
+		w = &progressWriter{w: w, written: d.offset, total: d.total, progress: d.progress}
+	}
+	body := &readErrReader{r: resp.Body}
+	n, err := io.Copy(w, body)
+	d.offset += n
+	d.readFailed = body.err != nil
+	if err != nil {
+		return n, fmt.Errorf("%w: %w", errStreamFailed, err)
+	}
+	if d.hash != nil {
+		if sum := hex.EncodeToString(d.hash.Sum(nil)); !strings.EqualFold(sum, d.expected) {
+			return n, fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, d.expected, sum)
+		}
+	}
+	return n, nil
+}
+
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress func(written int64, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress(p.written, p.total)
+	return n, err
+}
+
+// readErrReader 记录读取错误，用于区分响应体中断与 writer 写入失败
+type readErrReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func isSuccess(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
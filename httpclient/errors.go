@@ -0,0 +1,34 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// maxErrorBodyLen HTTPError.Error 中包含的响应体长度上限
+const maxErrorBodyLen = 256
+
+// HTTPError 非预期状态码的响应，errors.Is(err, ErrFailedRequest) 为 true
+type HTTPError struct {
+	StatusCode int
+	URL        string
+	Header     http.Header
+	// Body 响应体，最大 10MB
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	body := e.Body
+	if len(body) > maxErrorBodyLen {
+		body = body[:maxErrorBodyLen]
+	}
+	return fmt.Sprintf("failed request: url:(%s) status code:%d body:%s", e.URL, e.StatusCode, body)
+}
+
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrFailedRequest
+}
+
+func newHTTPError(resp dalResponse) *HTTPError {
+	return &HTTPError{StatusCode: resp.status, URL: resp.url, Header: resp.header, Body: resp.body}
+}
@@ -0,0 +1,62 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithHedge 对 GET 请求启用对冲：delay 后仍未返回时再发送一个相同请求，取先成功的响应并取消另一个。
+// 只作用于 GetJson 等幂等的 GET 调用，其他方法忽略该选项
+func WithHedge(delay time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.hedgeDelay = delay
+	}
+}
+
+type attemptResult struct {
+	resp dalResponse
+	err  error
+}
+
+// attemptHedged 按对冲配置发送一次尝试，两个请求都失败时返回后返回的错误
+func (c *DalHttpClient) attemptHedged(ctx context.Context, r dalRequest, attempt int) (dalResponse, error) {
+	if r.hedgeDelay <= 0 || r.method != http.MethodGet || r.stream != nil || r.download != nil {
+		return c.attempt(ctx, r, attempt)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	// 返回时取消未完成的请求
+	defer cancel()
+	results := make(chan attemptResult, 2)
+	send := func() {
+		resp, err := c.attempt(ctx, r, attempt)
+		results <- attemptResult{resp: resp, err: err}
+	}
+	go send()
+
+	timer := time.NewTimer(r.hedgeDelay)
+	defer timer.Stop()
+	pending := 1
+	hedged := false
+	var last attemptResult
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				pending++
+				go send()
+			}
+		case last = <-results:
+			pending--
+			if last.err == nil {
+				return last.resp, nil
+			}
+			// 第一个请求在对冲前就失败时不再对冲，交给重试处理
+			if !hedged {
+				return last.resp, last.err
+			}
+		}
+	}
+	return last.resp, last.err
+}
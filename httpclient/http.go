@@ -1,164 +1,163 @@
 package httpclient
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
-	"strings"
 	"time"
 
 	"github.com/bytedance/sonic"
 	errors2 "github.com/pkg/errors"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
 type DalHttpClient struct {
 	httpClient *http.Client
 	dalLog     *zap.Logger
+	retry      RetryConfig
+	policy     RetryPolicy
+	breakers   *breakerGroup
+	opts       options
+	// timeout 每次尝试的默认超时
+	timeout time.Duration
+	stats   *poolStats
+	limiter *hostLimiter
+	tokens  *tokenCache
 }
 
 type DalHttpClientConf struct {
+	// Timeout 每次尝试的默认超时，可用 WithTimeout 按调用覆盖
 	Timeout time.Duration
 	DalLog  *zap.Logger
+	// Retry 重试配置，默认不重试
+	Retry RetryConfig
+	// RetryPolicy 自定义重试策略，设置时替代 Retry 决定是否重试。GetWithRetry 仍使用 Retry 的退避配置
+	RetryPolicy RetryPolicy
+	// Breaker 按 host 熔断，默认不启用
+	Breaker BreakerConfig
+	// TLS mTLS 与自定义 CA 配置
+	TLS TLSConfig
+	// Transport 连接池配置
+	Transport TransportConfig
+	// RateLimit 按 host 限流，默认不启用
+	RateLimit RateLimitConfig
 }
 
+// ErrFailedRequest 响应状态码不符合预期，具体的状态码与响应体见 *HTTPError
 var ErrFailedRequest = errors.New("failed request")
 
-func NewDalHttpClient(conf DalHttpClientConf) *DalHttpClient {
+func NewDalHttpClient(conf DalHttpClientConf, opts ...Option) *DalHttpClient {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	stats := &poolStats{}
+	var policy RetryPolicy = conf.Retry
+	if conf.RetryPolicy != nil {
+		policy = conf.RetryPolicy
+	}
+	var transport http.RoundTripper = newTransport(conf.Transport, conf.TLS, o, stats)
+	if o.transport != nil {
+		transport = o.transport
+	}
+	httpClient := &http.Client{Transport: transport}
+	if len(o.allowedHosts) > 0 {
+		httpClient.CheckRedirect = o.checkRedirect
+	}
 	return &DalHttpClient{
-		httpClient: &http.Client{Timeout: conf.Timeout, Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 100,
-			IdleConnTimeout:     60 * time.Second,
-			Proxy:               http.ProxyFromEnvironment,
-		}},
-		dalLog: conf.DalLog,
+		httpClient: httpClient,
+		dalLog:     conf.DalLog,
+		retry:      conf.Retry,
+		policy:     policy,
+		breakers:   newBreakerGroup(conf.Breaker, conf.DalLog),
+		opts:       o,
+		timeout:    conf.Timeout,
+		stats:      stats,
+		limiter:    newHostLimiter(conf.RateLimit),
+		tokens:     newTokenCache(o, httpClient),
 	}
 }
 
-func (c *DalHttpClient) PostJson(ctx context.Context, url string, headers map[string]string, data any, resp any) error {
-	jsonData, err := sonic.Marshal(data)
-	if err != nil {
-		return err
+// withHeader 复制 headers 并设置 k，避免修改调用方的 map
+func withHeader(headers map[string]string, k string, v string) map[string]string {
+	res := make(map[string]string, len(headers)+1)
+	for hk, hv := range headers {
+		res[hk] = hv
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	res[k] = v
+	return res
+}
+
+func (c *DalHttpClient) PostJson(ctx context.Context, url string, headers map[string]string, data any, resp any, opts ...CallOption) error {
+	jsonData, err := sonic.Marshal(data)
 	if err != nil {
 		return err
 	}
 	if _, exists := headers["Content-Type"]; !exists {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	headerSb := strings.Builder{}
-	headerSb.Grow(len(headers) * 20)
-	for k, v := range headers {
-		req.Header.Set(k, v)
-		headerSb.WriteString(fmt.Sprintf("(%s:%s),", k, v))
+		headers = withHeader(headers, "Content-Type", "application/json")
 	}
-	start := time.Now()
-	rawResponse, err := c.httpClient.Do(req)
+	rawResponse, err := c.do(ctx, dalRequest{
+		name:    "PostJson",
+		method:  http.MethodPost,
+		url:     url,
+		headers: headers,
+		body:    jsonData,
+		timeout: c.callTimeout(newCallOptions(opts)),
+	})
 	if err != nil {
 		return err
 	}
-	defer rawResponse.Body.Close()
-
-	// 限制响应体大小为 10MB
-	rawResponse.Body = http.MaxBytesReader(nil, rawResponse.Body, 10<<20)
-
-	bodyBytes, err := io.ReadAll(rawResponse.Body)
-	if err != nil {
-		if errors.Is(err, &http.MaxBytesError{}) {
-			return errors2.New("response body exceeds size limit")
-		}
-		return errors2.Wrap(err, "failed to read response body")
-	}
-	logFields := []zapcore.Field{
-		zap.Int("status", rawResponse.StatusCode),
-		zap.String("method", http.MethodPost),
-		zap.String("path", url),
-		zap.ByteString("data", jsonData),
-		zap.String("header", headerSb.String()),
-		zap.Int64("latency_ms", time.Since(start).Milliseconds()),
-		zap.ByteString("response", bodyBytes),
-	}
-	if rawResponse.StatusCode == http.StatusOK {
-		c.dalLog.Info("PostJson", logFields...)
-		err = sonic.Unmarshal(bodyBytes, resp)
-		return err
-	} else {
-		c.dalLog.Warn("PostJson", logFields...)
-		return ErrFailedRequest
+	if rawResponse.status != http.StatusOK {
+		return newHTTPError(rawResponse)
 	}
+	return sonic.Unmarshal(rawResponse.body, resp)
 }
 
-func (c *DalHttpClient) GetWithRetry(baseUrl string, params map[string]string, headers map[string]string, maxRetries int) ([]byte, error) {
-	fullUrl := baseUrl
-	if len(params) > 0 {
-		urlParams := url.Values{}
-		for k, v := range params {
-			urlParams.Add(k, v)
-		}
-		fullUrl = baseUrl + "?" + urlParams.Encode()
-	}
-	req, err := http.NewRequest("GET", fullUrl, nil)
-	if err != nil {
-		return nil, err
+// GetWithRetry 发送 GET 请求，网络错误或非 200 响应时最多尝试 maxRetries 次。
+// 重试间隔按 Retry 配置的 InitialBackoff 与 MaxBackoff 指数退避并随机抖动，ctx 结束时立即返回。
+// 与其他方法一样经过 host 白名单、熔断、限流、hooks 与链路追踪，客户端本地错误不重试
+func (c *DalHttpClient) GetWithRetry(ctx context.Context, baseUrl string, params map[string]string, headers map[string]string, maxRetries int) ([]byte, error) {
+	r := dalRequest{
+		name:    "GetWithRetry",
+		method:  http.MethodGet,
+		url:     withQuery(baseUrl, params),
+		headers: headers,
+		timeout: c.timeout,
 	}
 
-	// 构建请求头日志字符串
-	headerSb := strings.Builder{}
-	headerSb.Grow(len(headers) * 20)
-	if len(headers) > 0 {
-		for k, v := range headers {
-			req.Header.Add(k, v)
-			headerSb.WriteString(fmt.Sprintf("(%s:%s),", k, v))
-		}
-	}
-	headerStr := headerSb.String()
-
 	var lastErr error
 	for i := 0; i < maxRetries; i++ {
-		start := time.Now()
-		resp, err := c.httpClient.Do(req)
-		currentLatency := time.Since(start).Milliseconds()
-
-		if err != nil {
-			lastErr = err
-			time.Sleep(time.Millisecond * time.Duration(i+1*50)) // 指数退避
-			continue
+		if i > 0 {
+			if err := sleepContext(ctx, c.retry.backoff(i-1)); err != nil {
+				return nil, errors2.WithStack(fmt.Errorf("after %d retries, last error: %v: %w", i, lastErr, err))
+			}
 		}
-
-		// 读取响应体
-		bodyBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close() // 显式关闭body，避免循环中defer导致的资源泄露
-
+		resp, err := c.attempt(ctx, r, i)
 		if err != nil {
-			lastErr = errors2.WithStack(err)
-			time.Sleep(time.Millisecond * time.Duration(i+1*50))
+			if isClientErr(err) {
+				return nil, errors2.WithStack(err)
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
 			continue
 		}
-
-		// 记录日志
-		logFields := []zapcore.Field{
-			zap.Int("status", resp.StatusCode),
-			zap.String("method", "GET"),
-			zap.String("path", fullUrl),
-			zap.String("header", headerStr),
-			zap.Int64("latency_ms", currentLatency),
-			zap.ByteString("response", bodyBytes),
-		}
-		c.dalLog.Info("GetWithRetry", logFields...)
-		if resp.StatusCode == http.StatusOK {
-			return bodyBytes, nil
+		if resp.status == http.StatusOK {
+			return resp.body, nil
 		}
 
-		lastErr = fmt.Errorf("url:(%s) status code:%d", fullUrl, resp.StatusCode)
-		time.Sleep(time.Millisecond * time.Duration(i+1*50))
+		lastErr = fmt.Errorf("url:(%s) status code:%d", r.url, resp.status)
 	}
 
 	return nil, errors2.WithStack(fmt.Errorf("after %d retries, last error: %v", maxRetries, lastErr))
 }
+
+// callTimeout 本次调用每次尝试的超时，0 表示不设超时
+func (c *DalHttpClient) callTimeout(o callOptions) time.Duration {
+	if o.hasTimeout {
+		return max(o.timeout, 0)
+	}
+	return c.timeout
+}
@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const defaultIdempotencyHeader = "Idempotency-Key"
+
+// WithIdempotencyKey 开启重试时为 POST、PUT、PATCH 请求生成幂等键，所有尝试使用同一个值，
+// header 为空时使用 Idempotency-Key。调用方已设置该请求头时不覆盖
+func WithIdempotencyKey(header string) Option {
+	return func(o *options) {
+		if header == "" {
+			header = defaultIdempotencyHeader
+		}
+		o.idempotencyHeader = header
+	}
+}
+
+// withIdempotencyKey 按 WithIdempotencyKey 为可能重试的非安全请求设置幂等键
+func (c *DalHttpClient) withIdempotencyKey(r dalRequest) dalRequest {
+	if c.opts.idempotencyHeader == "" || r.stream != nil || !c.mayRetry(r.method) {
+		return r
+	}
+	switch r.method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return r
+	}
+	for k := range r.headers {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(c.opts.idempotencyHeader) {
+			return r
+		}
+	}
+	r.headers = withHeader(r.headers, c.opts.idempotencyHeader, newIdempotencyKey())
+	return r
+}
+
+// mayRetry 默认策略下按 MaxAttempts 与 RetryNonIdempotent 判断，自定义策略视为可能重试
+func (c *DalHttpClient) mayRetry(method string) bool {
+	if conf, ok := c.policy.(RetryConfig); ok {
+		return conf.attempts() > 1 && (conf.RetryNonIdempotent || isIdempotent(method))
+	}
+	return true
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// GetJson 发送 GET 请求并将 JSON 响应解码到 resp，params 编码为查询参数。非 2xx 响应返回 *HTTPError
+func (c *DalHttpClient) GetJson(ctx context.Context, baseUrl string, params map[string]string, headers map[string]string, resp any, opts ...CallOption) error {
+	return c.sendJson(ctx, "GetJson", http.MethodGet, withQuery(baseUrl, params), headers, nil, resp, opts)
+}
+
+// PutJson 以 JSON 发送 data 并将响应解码到 resp。非 2xx 响应返回 *HTTPError
+func (c *DalHttpClient) PutJson(ctx context.Context, url string, headers map[string]string, data any, resp any, opts ...CallOption) error {
+	return c.sendJson(ctx, "PutJson", http.MethodPut, url, headers, data, resp, opts)
+}
+
+// PatchJson 以 JSON 发送 data 并将响应解码到 resp。非 2xx 响应返回 *HTTPError
+func (c *DalHttpClient) PatchJson(ctx context.Context, url string, headers map[string]string, data any, resp any, opts ...CallOption) error {
+	return c.sendJson(ctx, "PatchJson", http.MethodPatch, url, headers, data, resp, opts)
+}
+
+// DeleteJson 发送 DELETE 请求并将响应解码到 resp。非 2xx 响应返回 *HTTPError
+func (c *DalHttpClient) DeleteJson(ctx context.Context, url string, headers map[string]string, resp any, opts ...CallOption) error {
+	return c.sendJson(ctx, "DeleteJson", http.MethodDelete, url, headers, nil, resp, opts)
+}
+
+// sendJson data 为 nil 时不发送请求体
+func (c *DalHttpClient) sendJson(ctx context.Context, name string, method string, url string, headers map[string]string, data any, resp any, opts []CallOption) error {
+	rawResponse, err := c.callJson(ctx, name, method, url, headers, data, opts)
+	if err != nil {
+		return err
+	}
+	return decodeJson(rawResponse, resp)
+}
+
+// callJson 以 JSON 发送 data 并返回原始响应
+func (c *DalHttpClient) callJson(ctx context.Context, name string, method string, url string, headers map[string]string, data any, opts []CallOption) (dalResponse, error) {
+	o := newCallOptions(opts)
+	var body []byte
+	if data != nil {
+		jsonData, err := sonic.Marshal(data)
+		if err != nil {
+			return dalResponse{}, err
+		}
+		body = jsonData
+		if _, exists := headers["Content-Type"]; !exists {
+			headers = withHeader(headers, "Content-Type", "application/json")
+		}
+	}
+	if _, exists := headers["Accept"]; !exists {
+		headers = withHeader(headers, "Accept", "application/json")
+	}
+	return c.do(ctx, dalRequest{
+		name:       name,
+		method:     method,
+		url:        url,
+		headers:    headers,
+		body:       body,
+		timeout:    c.callTimeout(o),
+		hedgeDelay: o.hedgeDelay,
+		endpoint:   o.endpoint,
+	})
+}
+
+// decodeJson 非 2xx 响应返回 *HTTPError；resp 为 nil 或响应体为空时不解码
+func decodeJson(rawResponse dalResponse, resp any) error {
+	if !isSuccess(rawResponse.status) {
+		return newHTTPError(rawResponse)
+	}
+	if resp == nil || len(rawResponse.body) == 0 {
+		return nil
+	}
+	return sonic.Unmarshal(rawResponse.body, resp)
+}
+
+// withQuery 将 params 编码为查询参数追加到 baseUrl，baseUrl 可已带查询参数
+func withQuery(baseUrl string, params map[string]string) string {
+	if len(params) == 0 {
+		return baseUrl
+	}
+	urlParams := url.Values{}
+	for k, v := range params {
+		urlParams.Add(k, v)
+	}
+	if strings.Contains(baseUrl, "?") {
+		return baseUrl + "&" + urlParams.Encode()
+	}
+	return baseUrl + "?" + urlParams.Encode()
+}
@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// FilePart multipart 上传的文件
+type FilePart struct {
+	// FieldName 表单字段名
+	FieldName string
+	FileName  string
+	// ContentType 默认 application/octet-stream
+	ContentType string
+	// Reader 文件内容，上传时流式读取，不会被关闭
+	Reader io.Reader
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// PostMultipart 以 multipart/form-data 上传表单字段与文件，并将 JSON 响应解码到 resp。
+// 文件内容边读边发，不整体读入内存，因此不会重试；dal 日志只记录字段名与文件名。非 2xx 响应返回 *HTTPError
+func (c *DalHttpClient) PostMultipart(ctx context.Context, url string, fields map[string]string, files []FilePart, resp any, opts ...CallOption) error {
+	pr, pw := io.Pipe()
+	// 请求提前结束时让写入协程退出
+	defer pr.Close()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+
+	fieldNames := make([]string, 0, len(fields))
+	for k := range fields {
+		fieldNames = append(fieldNames, k)
+	}
+	fileNames := make([]string, 0, len(files))
+	for _, f := range files {
+		fileNames = append(fileNames, f.FieldName+":"+f.FileName)
+	}
+	rawResponse, err := c.do(ctx, dalRequest{
+		name:    "PostMultipart",
+		method:  http.MethodPost,
+		url:     url,
+		headers: map[string]string{"Content-Type": mw.FormDataContentType()},
+		stream:  pr,
+		timeout: c.callTimeout(newCallOptions(opts)),
+		logFields: []zap.Field{
+			zap.Strings("fields", fieldNames),
+			zap.Strings("files", fileNames),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return decodeJson(rawResponse, resp)
+}
+
+func writeMultipart(mw *multipart.Writer, fields map[string]string, files []FilePart) error {
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(f.FieldName), quoteEscaper.Replace(f.FileName)))
+		h.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(part, f.Reader); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
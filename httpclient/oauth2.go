@@ -0,0 +1,164 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+const defaultTokenRefreshBefore = time.Minute
+
+// Token OAuth2 访问令牌
+type Token struct {
+	AccessToken string
+	// Expiry 过期时间，零值表示不过期
+	Expiry time.Time
+}
+
+// TokenSource 获取访问令牌，用于自定义的令牌接口
+type TokenSource interface {
+	Token(ctx context.Context) (Token, error)
+}
+
+// ClientCredentialsConfig OAuth2 client credentials 模式配置
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// EndpointParams 额外的表单参数，例如 audience
+	EndpointParams url.Values
+	// AuthInParams 为 true 时以表单参数发送 client_id 与 client_secret，否则使用 HTTP Basic 认证
+	AuthInParams bool
+}
+
+// WithOAuth2ClientCredentials 以 client credentials 模式获取并缓存令牌，在过期前 refreshBefore 刷新（<= 0 时为 1 分钟）。
+// 每次尝试设置 Authorization: Bearer 请求头，响应 401 时丢弃缓存的令牌并重新获取后重试一次
+func WithOAuth2ClientCredentials(conf ClientCredentialsConfig, refreshBefore time.Duration) Option {
+	return func(o *options) {
+		o.tokenConf = &conf
+		o.tokenRefreshBefore = refreshBefore
+	}
+}
+
+// WithTokenSource 使用自定义 TokenSource，缓存与 401 重试同 WithOAuth2ClientCredentials
+func WithTokenSource(src TokenSource, refreshBefore time.Duration) Option {
+	return func(o *options) {
+		o.tokenSource = src
+		o.tokenRefreshBefore = refreshBefore
+	}
+}
+
+// newTokenCache 按选项创建令牌缓存，未配置时返回 nil
+func newTokenCache(o options, httpClient *http.Client) *tokenCache {
+	src := o.tokenSource
+	if src == nil && o.tokenConf != nil {
+		src = &clientCredentials{conf: *o.tokenConf, httpClient: httpClient}
+	}
+	if src == nil {
+		return nil
+	}
+	refreshBefore := o.tokenRefreshBefore
+	if refreshBefore <= 0 {
+		refreshBefore = defaultTokenRefreshBefore
+	}
+	return &tokenCache{src: src, refreshBefore: refreshBefore}
+}
+
+// tokenCache 缓存令牌，并发获取时只请求一次
+type tokenCache struct {
+	src           TokenSource
+	refreshBefore time.Duration
+
+	mu    sync.Mutex
+	token Token
+}
+
+func (t *tokenCache) get(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token.AccessToken != "" && (t.token.Expiry.IsZero() || time.Until(t.token.Expiry) > t.refreshBefore) {
+		return t.token.AccessToken, nil
+	}
+	token, err := t.src.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	t.token = token
+	return token.AccessToken, nil
+}
+
+// invalidate 丢弃 used 对应的缓存令牌，已被其他请求刷新时不处理
+func (t *tokenCache) invalidate(used string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token.AccessToken == used {
+		t.token = Token{}
+	}
+}
+
+type clientCredentials struct {
+	conf       ClientCredentialsConfig
+	httpClient *http.Client
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func (c *clientCredentials) Token(ctx context.Context) (Token, error) {
+	form := url.Values{}
+	for k, v := range c.conf.EndpointParams {
+		form[k] = v
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(c.conf.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.conf.Scopes, " "))
+	}
+	if c.conf.AuthInParams {
+		form.Set("client_id", c.conf.ClientID)
+		form.Set("client_secret", c.conf.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !c.conf.AuthInParams {
+		req.SetBasicAuth(url.QueryEscape(c.conf.ClientID), url.QueryEscape(c.conf.ClientSecret))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Token{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("oauth2: token endpoint returned status %d: %s", resp.StatusCode, body)
+	}
+	var tr tokenResponse
+	if err = sonic.Unmarshal(body, &tr); err != nil {
+		return Token{}, err
+	}
+	if tr.AccessToken == "" {
+		return Token{}, fmt.Errorf("oauth2: token endpoint returned no access_token")
+	}
+	token := Token{AccessToken: tr.AccessToken}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"hash"
+	"net/http"
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+	gzipMinSize   int
+
+	idempotencyHeader string
+
+	tokenConf          *ClientCredentialsConfig
+	tokenSource        TokenSource
+	tokenRefreshBefore time.Duration
+
+	transport http.RoundTripper
+
+	allowedHosts    []string
+	blockPrivateIPs bool
+	dnsCache        *DNSCacheConfig
+	respCache       *responseCache
+}
+
+// RequestHook 每次尝试发送前调用，可用于注入鉴权头等
+type RequestHook func(req *http.Request)
+
+// ResponseHook 每次收到响应后调用，latency 为发送到收到响应头的耗时。
+// 响应体由客户端读取，hook 中不应读取或关闭 Body
+type ResponseHook func(resp *http.Response, latency time.Duration)
+
+// WithRequestHook 添加请求 hook，多次调用按添加顺序执行
+func WithRequestHook(hook RequestHook) Option {
+	return func(o *options) {
+		o.requestHooks = append(o.requestHooks, hook)
+	}
+}
+
+// WithResponseHook 添加响应 hook，多次调用按添加顺序执行
+func WithResponseHook(hook ResponseHook) Option {
+	return func(o *options) {
+		o.responseHooks = append(o.responseHooks, hook)
+	}
+}
+
+// WithTransport 替换底层 RoundTripper，用于测试中的 mock 与录制回放。设置后 TLS、Transport 配置与 Stats 中的连接计数不生效
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// CallOption 单次调用的选项
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout    time.Duration
+	hasTimeout bool
+
+	hedgeDelay time.Duration
+	// endpoint 记录在 dal 日志中的接口名，由 Service 设置
+	endpoint string
+
+	progress func(written int64, total int64)
+	hash     hash.Hash
+	expected string
+
+	rangeStart int64
+	rangeEnd   int64
+	hasRange   bool
+	maxResumes int
+}
+
+func newCallOptions(opts []CallOption) callOptions {
+	o := callOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTimeout 覆盖本次调用每次尝试的超时，d <= 0 时本次调用不设超时。ctx 的 deadline 始终生效
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+		o.hasTimeout = true
+	}
+}
+
+func withEndpoint(endpoint string) CallOption {
+	return func(o *callOptions) {
+		o.endpoint = endpoint
+	}
+}
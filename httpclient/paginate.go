@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"time"
+)
+
+// ErrPageLimit 达到 MaxPages 时仍有下一页
+var ErrPageLimit = errors.New("page limit exceeded")
+
+// PageConfig 分页 GET 接口的配置
+type PageConfig[T any] struct {
+	URL     string
+	Params  map[string]string
+	Headers map[string]string
+	// CursorParam 页码或游标的查询参数名，例如 page、cursor
+	CursorParam string
+	// FirstCursor 首页的页码或游标，为空时首页不带 CursorParam
+	FirstCursor string
+	// Extract 从响应体中解析本页数据与下一页的页码或游标，next 为空表示没有下一页
+	Extract func(body []byte) (items []T, next string, err error)
+	// MaxPages 最多请求的页数，超过时返回 ErrPageLimit，<= 0 表示不限制
+	MaxPages int
+	// Timeout 整个遍历的超时，<= 0 表示只受 ctx 限制
+	Timeout time.Duration
+}
+
+// Paginate 依次请求每一页并逐条返回数据，出错时返回一次错误后结束。调用方提前退出循环时不再请求后续页面。
+// 每一页的请求与其他方法一样记录 dal 日志并按重试策略重试
+func Paginate[T any](ctx context.Context, c *DalHttpClient, conf PageConfig[T], opts ...CallOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if conf.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, conf.Timeout)
+			defer cancel()
+		}
+		o := newCallOptions(opts)
+		cursor := conf.FirstCursor
+		for page := 0; ; page++ {
+			if conf.MaxPages > 0 && page >= conf.MaxPages {
+				yield(zero, ErrPageLimit)
+				return
+			}
+			params := conf.Params
+			if cursor != "" {
+				params = withHeader(conf.Params, conf.CursorParam, cursor)
+			}
+			rawResponse, err := c.do(ctx, dalRequest{
+				name:     "Paginate",
+				method:   http.MethodGet,
+				url:      withQuery(conf.URL, params),
+				headers:  conf.Headers,
+				timeout:  c.callTimeout(o),
+				endpoint: o.endpoint,
+			})
+			if err == nil && !isSuccess(rawResponse.status) {
+				err = newHTTPError(rawResponse)
+			}
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			items, next, err := conf.Extract(rawResponse.body)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			cursor = next
+		}
+	}
+}
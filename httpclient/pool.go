@@ -0,0 +1,143 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransportConfig 连接池配置，零值字段使用默认值
+type TransportConfig struct {
+	// MaxIdleConns 默认 100
+	MaxIdleConns int
+	// MaxIdleConnsPerHost 默认 100
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost 每个 host 的最大连接数，默认不限制
+	MaxConnsPerHost int
+	// IdleConnTimeout 默认 60s
+	IdleConnTimeout time.Duration
+	// DialTimeout 默认 30s
+	DialTimeout time.Duration
+	// KeepAlive TCP keep-alive 间隔，默认 30s
+	KeepAlive time.Duration
+	// TLSHandshakeTimeout 默认 10s
+	TLSHandshakeTimeout time.Duration
+	// ExpectContinueTimeout 请求带 Expect: 100-continue 时等待服务端响应的时长，默认 1s
+	ExpectContinueTimeout time.Duration
+	// DisableCompression 关闭 Transport 的自动 gzip 解压，调用方设置 Accept-Encoding 时客户端仍会解压 gzip/deflate 响应
+	DisableCompression bool
+}
+
+func (t TransportConfig) withDefaults() TransportConfig {
+	if t.MaxIdleConns <= 0 {
+		t.MaxIdleConns = 100
+	}
+	if t.MaxIdleConnsPerHost <= 0 {
+		t.MaxIdleConnsPerHost = 100
+	}
+	if t.IdleConnTimeout <= 0 {
+		t.IdleConnTimeout = 60 * time.Second
+	}
+	if t.DialTimeout <= 0 {
+		t.DialTimeout = 30 * time.Second
+	}
+	if t.KeepAlive <= 0 {
+		t.KeepAlive = 30 * time.Second
+	}
+	if t.TLSHandshakeTimeout <= 0 {
+		t.TLSHandshakeTimeout = 10 * time.Second
+	}
+	if t.ExpectContinueTimeout <= 0 {
+		t.ExpectContinueTimeout = time.Second
+	}
+	return t
+}
+
+// PoolStats 连接池统计，计数均为客户端创建以来的累计值
+type PoolStats struct {
+	// Dials 建立连接的次数
+	Dials int64
+	// DialErrors 建立连接失败的次数
+	DialErrors int64
+	// ConnsReused 复用空闲连接的请求数
+	ConnsReused int64
+	// OpenConns 当前打开的连接数
+	OpenConns int64
+	// InFlight 当前执行中的请求数
+	InFlight int64
+	// IdleConns 当前空闲连接数，按 OpenConns - InFlight 估算，仅对 HTTP/1.1 准确
+	IdleConns int64
+}
+
+type poolStats struct {
+	dials      atomic.Int64
+	dialErrors atomic.Int64
+	reused     atomic.Int64
+	open       atomic.Int64
+	inFlight   atomic.Int64
+}
+
+func newTransport(conf TransportConfig, tlsConf TLSConfig, o options, stats *poolStats) *http.Transport {
+	conf = conf.withDefaults()
+	dialer := &net.Dialer{Timeout: conf.DialTimeout, KeepAlive: conf.KeepAlive}
+	proxy := http.ProxyFromEnvironment
+	if o.blockPrivateIPs {
+		dialer.Control = blockPrivateControl
+		proxy = nil
+	}
+	dial := dialer.DialContext
+	if o.dnsCache != nil {
+		dial = newDNSCache(*o.dnsCache).dialContext(dialer)
+	}
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			stats.dials.Add(1)
+			conn, err := dial(ctx, network, addr)
+			if err != nil {
+				stats.dialErrors.Add(1)
+				return nil, err
+			}
+			stats.open.Add(1)
+			return &countedConn{Conn: conn, stats: stats}, nil
+		},
+		MaxIdleConns:          conf.MaxIdleConns,
+		MaxIdleConnsPerHost:   conf.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       conf.MaxConnsPerHost,
+		IdleConnTimeout:       conf.IdleConnTimeout,
+		TLSHandshakeTimeout:   conf.TLSHandshakeTimeout,
+		ExpectContinueTimeout: conf.ExpectContinueTimeout,
+		DisableCompression:    conf.DisableCompression,
+		TLSClientConfig:       tlsConf.build(),
+	}
+}
+
+// countedConn 关闭时减少打开的连接数
+type countedConn struct {
+	net.Conn
+	stats *poolStats
+	once  sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() {
+		c.stats.open.Add(-1)
+	})
+	return c.Conn.Close()
+}
+
+// Stats 返回连接池统计
+func (c *DalHttpClient) Stats() PoolStats {
+	s := PoolStats{
+		Dials:       c.stats.dials.Load(),
+		DialErrors:  c.stats.dialErrors.Load(),
+		ConnsReused: c.stats.reused.Load(),
+		OpenConns:   c.stats.open.Load(),
+		InFlight:    c.stats.inFlight.Load(),
+	}
+	s.IdleConns = max(s.OpenConns-s.InFlight, 0)
+	return s
+}
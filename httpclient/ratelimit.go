@@ -0,0 +1,143 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimitError 请求超过 host 的限流阈值，可用 errors.Is(err, ErrRateLimited) 判断
+type RateLimitError struct {
+	Host string
+	// RetryAfter 下一个令牌可用前需要等待的时长
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited: host %s, retry after %s", e.Host, e.RetryAfter)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimit 令牌桶配置
+type RateLimit struct {
+	// QPS 每秒请求数，<= 0 表示不限流
+	QPS float64
+	// Burst 桶容量，默认 max(QPS, 1)
+	Burst int
+}
+
+// RateLimitConfig 按 host 限流，每次尝试（包括重试）消耗一个令牌
+type RateLimitConfig struct {
+	// Default 未在 Hosts 中配置的 host 使用的限流，零值表示不限流
+	Default RateLimit
+	// Hosts 按 host（含端口，与请求 URL 中一致）单独配置
+	Hosts map[string]RateLimit
+	// Wait 为 true 时在 ctx 内等待令牌，否则立即返回 *RateLimitError
+	Wait bool
+}
+
+// hostLimiter 每个 host 一个令牌桶
+type hostLimiter struct {
+	conf RateLimitConfig
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+func newHostLimiter(conf RateLimitConfig) *hostLimiter {
+	if conf.Default.QPS <= 0 && len(conf.Hosts) == 0 {
+		return nil
+	}
+	return &hostLimiter{conf: conf, buckets: make(map[string]*rateBucket)}
+}
+
+// wait 获取一个令牌，未启用限流时直接返回
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	b := l.bucket(host)
+	if b == nil {
+		return nil
+	}
+	d := b.reserve(time.Now(), l.conf.Wait)
+	if d == 0 {
+		return nil
+	}
+	if !l.conf.Wait {
+		return &RateLimitError{Host: host, RetryAfter: d}
+	}
+	if err := sleepContext(ctx, d); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
+
+// bucket host 不限流时返回 nil
+func (l *hostLimiter) bucket(host string) *rateBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[host]; ok {
+		return b
+	}
+	limit, ok := l.conf.Hosts[host]
+	if !ok {
+		limit = l.conf.Default
+	}
+	var b *rateBucket
+	if limit.QPS > 0 {
+		b = newRateBucket(limit)
+	}
+	l.buckets[host] = b
+	return b
+}
+
+// rateBucket 令牌桶，等待模式下令牌可以为负，表示已预约的请求
+type rateBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateBucket(limit RateLimit) *rateBucket {
+	burst := float64(limit.Burst)
+	if burst <= 0 {
+		burst = math.Max(limit.QPS, 1)
+	}
+	return &rateBucket{rate: limit.QPS, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// reserve 有令牌时消耗并返回 0，否则返回需要等待的时长。consume 为 true 时无论是否需要等待都预约一个令牌
+func (b *rateBucket) reserve(now time.Time, consume bool) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	d := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if consume {
+		b.tokens--
+	}
+	return d
+}
+
+// cancel 归还等待中被取消的预约
+func (b *rateBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
@@ -0,0 +1,275 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+	errors2 "github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxResponseSize 响应体大小上限 10MB
+const maxResponseSize = 10 << 20
+
+var errResponseTooLarge = errors2.New("response body exceeds size limit")
+
+// dalRequest 一次出站请求，body 在重试时复用
+type dalRequest struct {
+	// name 记录在 dal 日志中的方法名，例如 PostJson
+	name    string
+	method  string
+	url     string
+	headers map[string]string
+	body    []byte
+	// stream 流式请求体，设置时忽略 body 且不重试
+	stream io.Reader
+	// logFields 替代 data 记录在 dal 日志中的请求体描述，用于不便记录原文的请求体
+	logFields []zapcore.Field
+	// download 设置时 2xx 响应体直接写出，不读入内存
+	download *download
+	// timeout 每次尝试的超时，包括读取响应体，0 表示不设超时
+	timeout time.Duration
+	// hedgeDelay GET 请求的对冲延迟，0 表示不对冲
+	hedgeDelay time.Duration
+	// endpoint Service 的接口名，记录在 dal 日志中
+	endpoint string
+}
+
+// dalResponse 已读取完整响应体的响应
+type dalResponse struct {
+	status int
+	url    string
+	header http.Header
+	body   []byte
+	// token 本次请求使用的 OAuth2 令牌
+	token string
+}
+
+// do 发送请求，配置了 WithResponseCache 时 GET 请求优先从缓存读取
+func (c *DalHttpClient) do(ctx context.Context, r dalRequest) (dalResponse, error) {
+	if c.opts.respCache.cacheable(r) {
+		return c.opts.respCache.get(ctx, r, c.send)
+	}
+	return c.send(ctx, r)
+}
+
+// send 按重试策略发送请求并读取响应体，每次尝试都记录 dal 日志。
+// 不再重试时返回最后一次的响应或错误
+func (c *DalHttpClient) send(ctx context.Context, r dalRequest) (dalResponse, error) {
+	r = c.compressRequest(r)
+	r = c.withIdempotencyKey(r)
+	reauthorized := false
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attemptHedged(ctx, r, attempt)
+		// 令牌失效时重新获取并重试一次
+		if err == nil && resp.status == http.StatusUnauthorized && c.tokens != nil && !reauthorized && r.stream == nil {
+			reauthorized = true
+			c.tokens.invalidate(resp.token)
+			continue
+		}
+		if isClientErr(err) || ctx.Err() != nil || r.stream != nil {
+			return resp, err
+		}
+		wait, retry := c.policy.ShouldRetry(RetryInfo{
+			Attempt: attempt + 1,
+			Method:  r.method,
+			Status:  resp.status,
+			Header:  resp.header,
+			Err:     err,
+		})
+		if !retry {
+			return resp, err
+		}
+		if sleepErr := sleepContext(ctx, wait); sleepErr != nil {
+			return resp, err
+		}
+	}
+}
+
+// isClientErr 客户端本地产生的错误，不交给 RetryPolicy
+func isClientErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, target := range []error{
+		errResponseTooLarge, errStreamFailed, ErrChecksumMismatch,
+		ErrCircuitOpen, ErrRateLimited, ErrHostNotAllowed, ErrBlockedAddress,
+		ErrRangeNotSupported, ErrResourceChanged,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// attempt 发送一次请求
+func (c *DalHttpClient) attempt(ctx context.Context, r dalRequest, attempt int) (_ dalResponse, err error) {
+	var body io.Reader
+	if r.stream != nil {
+		body = r.stream
+	} else if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	// reqCtx 仅用于本次尝试，超时计为下游失败
+	reqCtx := ctx
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	reqCtx = httptrace.WithClientTrace(reqCtx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.stats.reused.Add(1)
+			}
+		},
+	})
+	req, err := http.NewRequestWithContext(reqCtx, r.method, r.url, body)
+	if err != nil {
+		return dalResponse{}, err
+	}
+	// 透传请求 ID
+	if id := logger.RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(logger.RequestIDHeader, id)
+	}
+	headerSb := strings.Builder{}
+	headerSb.Grow(len(r.headers) * 20)
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+		if logger.IsSensitiveHeader(k) {
+			v = logger.FilteredValue
+		}
+		headerSb.WriteString(fmt.Sprintf("(%s:%s),", k, v))
+	}
+	var token string
+	if c.tokens != nil {
+		if token, err = c.tokens.get(ctx); err != nil {
+			return dalResponse{}, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	for _, hook := range c.opts.requestHooks {
+		hook(req)
+	}
+
+	if !c.opts.hostAllowed(req.URL.Hostname()) {
+		return dalResponse{}, fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Hostname())
+	}
+	host := req.URL.Host
+	if err = c.limiter.wait(ctx, host); err != nil {
+		return dalResponse{}, err
+	}
+	if !c.breakers.allow(host) {
+		return dalResponse{}, ErrCircuitOpen
+	}
+	span := startClientSpan(ctx, req)
+	status := 0
+	defer func() { endClientSpan(span, status, err) }()
+	c.stats.inFlight.Add(1)
+	defer c.stats.inFlight.Add(-1)
+	start := time.Now()
+	rawResponse, err := c.httpClient.Do(req)
+	// 调用方取消（包括对冲中落后的请求）不计入熔断
+	if ctx.Err() != nil {
+		c.breakers.release(host)
+	} else {
+		c.breakers.done(host, err != nil || rawResponse.StatusCode >= http.StatusInternalServerError)
+	}
+	depErr := err
+	if err == nil && rawResponse.StatusCode >= http.StatusInternalServerError {
+		depErr = ErrFailedRequest
+	}
+	metrics.Dependency(host).ObserveCall(time.Since(start), depErr)
+	if err == nil {
+		latency := time.Since(start)
+		for _, hook := range c.opts.responseHooks {
+			hook(rawResponse, latency)
+		}
+	}
+	logFields := []zapcore.Field{
+		zap.String("method", r.method),
+		zap.String("path", r.url),
+		zap.String("header", headerSb.String()),
+		logger.RequestIDField(ctx),
+	}
+	if r.endpoint != "" {
+		logFields = append(logFields, zap.String("endpoint", r.endpoint))
+	}
+	if r.logFields != nil {
+		logFields = append(logFields, r.logFields...)
+	} else {
+		logFields = append(logFields, zap.ByteString("data", r.body))
+	}
+	if attempt > 0 {
+		logFields = append(logFields, zap.Int("attempt", attempt+1))
+	}
+	if err != nil {
+		c.dalLog.Warn(r.name, append(logFields,
+			zap.Int64("latency_ms", time.Since(start).Milliseconds()),
+			zap.Error(err),
+		)...)
+		return dalResponse{}, err
+	}
+	defer rawResponse.Body.Close()
+	status = rawResponse.StatusCode
+
+	if r.download != nil && isSuccess(rawResponse.StatusCode) {
+		var n int64
+		n, err = r.download.copy(rawResponse)
+		logFields = append(logFields,
+			zap.Int("status", rawResponse.StatusCode),
+			zap.Int64("latency_ms", time.Since(start).Milliseconds()),
+			zap.Int64("bytes", n),
+		)
+		if err != nil {
+			c.dalLog.Warn(r.name, append(logFields, zap.Error(err))...)
+			return dalResponse{}, err
+		}
+		c.dalLog.Info(r.name, logFields...)
+		return dalResponse{status: rawResponse.StatusCode, url: r.url, header: rawResponse.Header}, nil
+	}
+
+	// 限制解压后的响应体大小
+	var bodyBytes []byte
+	respBody, err := decodeBody(rawResponse)
+	if err == nil {
+		bodyBytes, err = io.ReadAll(http.MaxBytesReader(nil, respBody, maxResponseSize))
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = errResponseTooLarge
+		} else {
+			err = errors2.Wrap(err, "failed to read response body")
+		}
+		c.dalLog.Warn(r.name, append(logFields,
+			zap.Int("status", rawResponse.StatusCode),
+			zap.Int64("latency_ms", time.Since(start).Milliseconds()),
+			zap.Error(err),
+		)...)
+		return dalResponse{}, err
+	}
+	logFields = append(logFields,
+		zap.Int("status", rawResponse.StatusCode),
+		zap.Int64("latency_ms", time.Since(start).Milliseconds()),
+		zap.ByteString("response", bodyBytes),
+	)
+	if rawResponse.StatusCode == http.StatusOK {
+		c.dalLog.Info(r.name, logFields...)
+	} else {
+		c.dalLog.Warn(r.name, logFields...)
+	}
+	return dalResponse{status: rawResponse.StatusCode, url: r.url, header: rawResponse.Header, body: bodyBytes, token: token}, nil
+}
@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/cacheproxy"
+)
+
+const responseCacheKeyPrefix = "httpclient:"
+
+// credentialHeaders 按用户区分的请求头，始终参与缓存 key 计算，避免一个用户的响应返回给其他调用方
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// ResponseCacheConfig GET 响应缓存配置
+type ResponseCacheConfig struct {
+	// TTL 默认缓存时长，<= 0 时只缓存带 Cache-Control: max-age 的响应
+	TTL time.Duration
+	// VaryHeaders 参与缓存 key 计算的请求头，例如 Accept-Language
+	VaryHeaders []string
+	// IgnoreCacheControl 为 true 时忽略响应的 Cache-Control，所有 200 响应都按 TTL 缓存。
+	// 默认 no-store、no-cache、private 的响应不缓存，max-age 覆盖 TTL
+	IgnoreCacheControl bool
+}
+
+// WithResponseCache 通过 cacheproxy 缓存 GET 的 200 响应体，缓存 key 由方法、URL、Authorization、Cookie 与 VaryHeaders 的值计算。
+// 命中缓存时不发送请求也不记录 dal 日志；Download 不使用缓存
+func WithResponseCache(proxy *cacheproxy.CacheProxy, conf ResponseCacheConfig) Option {
+	return func(o *options) {
+		o.respCache = &responseCache{proxy: proxy, conf: conf}
+	}
+}
+
+type responseCache struct {
+	proxy *cacheproxy.CacheProxy
+	conf  ResponseCacheConfig
+}
+
+func (rc *responseCache) cacheable(r dalRequest) bool {
+	return rc != nil && r.method == http.MethodGet && r.download == nil && r.stream == nil
+}
+
+// key 对方法、URL、凭证请求头与 VaryHeaders 的值取摘要，避免 URL 过长，凭证以摘要形式出现在 key 中
+func (rc *responseCache) key(r dalRequest) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.method+" "+r.url)
+	for _, name := range slices.Concat(credentialHeaders, rc.conf.VaryHeaders) {
+		var v string
+		for k, hv := range r.headers {
+			if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(name) {
+				v = hv
+			}
+		}
+		_, _ = io.WriteString(h, "\x00"+name+":"+v)
+	}
+	return responseCacheKeyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// get 命中缓存时直接返回，否则通过 send 请求并按配置写入缓存
+func (rc *responseCache) get(ctx context.Context, r dalRequest, send func(context.Context, dalRequest) (dalResponse, error)) (dalResponse, error) {
+	var fetched *dalResponse
+	var fetchErr error
+	getter := cacheproxy.SingleGetterV2Func(func(ctx context.Context, _ string) (string, bool, time.Duration, error) {
+		resp, err := send(ctx, r)
+		fetched, fetchErr = &resp, err
+		if err != nil {
+			return "", false, 0, err
+		}
+		ttl, ok := rc.ttl(resp)
+		if !ok {
+			// 跳过写入缓存，不计为回源失败
+			return "", false, 0, cacheproxy.ErrNotCacheable
+		}
+		return string(resp.body), false, ttl, nil
+	})
+	data, _, err := rc.proxy.GetHit(ctx, cacheproxy.CacheContext{ExpiredTime: rc.conf.TTL, EmptyExpiredTime: rc.conf.TTL}, rc.key(r), getter)
+	if fetched != nil {
+		// 本次调用执行了请求，直接返回原始响应
+		return *fetched, fetchErr
+	}
+	if err != nil {
+		// 与其他调用共享的回源不可缓存或失败时自行请求
+		return send(ctx, r)
+	}
+	return dalResponse{status: http.StatusOK, url: r.url, body: []byte(data)}, nil
+}
+
+// ttl 只缓存 200 响应
+func (rc *responseCache) ttl(resp dalResponse) (time.Duration, bool) {
+	if resp.status != http.StatusOK {
+		return 0, false
+	}
+	ttl := rc.conf.TTL
+	if !rc.conf.IgnoreCacheControl {
+		for _, directive := range strings.Split(resp.header.Get("Cache-Control"), ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			switch {
+			case directive == "no-store" || directive == "no-cache" || directive == "private":
+				return 0, false
+			case strings.HasPrefix(directive, "max-age="):
+				if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+					ttl = time.Duration(seconds) * time.Second
+				}
+			}
+		}
+	}
+	return ttl, ttl > 0
+}
@@ -0,0 +1,152 @@
+package httpclient
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 2 * time.Second
+	defaultMaxRetryAfter  = 30 * time.Second
+)
+
+// defaultRetryableStatus 未配置 RetryableStatus 时重试的状态码
+var defaultRetryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryInfo 一次尝试的结果
+type RetryInfo struct {
+	// Attempt 已完成的尝试次数，从 1 开始
+	Attempt int
+	Method  string
+	// Status 响应状态码，Err 不为 nil 时为 0
+	Status int
+	// Header 响应头，Err 不为 nil 时为 nil
+	Header http.Header
+	Err    error
+}
+
+// RetryPolicy 决定一次尝试后是否重试。熔断、限流、响应体超限等客户端错误与流式请求体不会交给 RetryPolicy，直接返回
+type RetryPolicy interface {
+	// ShouldRetry 返回重试前的等待时长与是否重试
+	ShouldRetry(info RetryInfo) (time.Duration, bool)
+}
+
+// RetryConfig 默认的 RetryPolicy。网络错误与 RetryableStatus 中的状态码会重试，响应带 Retry-After 时按其等待；
+// POST、PATCH 等非幂等方法只在 RetryNonIdempotent 时重试
+type RetryConfig struct {
+	// MaxAttempts 最大尝试次数（含首次请求），<= 1 时不重试
+	MaxAttempts int
+	// InitialBackoff 首次重试前的等待时间，之后每次翻倍，默认 100ms
+	InitialBackoff time.Duration
+	// MaxBackoff 单次等待时间上限，默认 2s
+	MaxBackoff time.Duration
+	// RetryableStatus 需要重试的状态码，为空时重试 429、500、502、503、504
+	RetryableStatus []int
+	// RetryNonIdempotent 允许重试 POST、PATCH 等非幂等方法
+	RetryNonIdempotent bool
+	// MaxRetryAfter 接受的 Retry-After 上限，超过时不再重试，默认 30s
+	MaxRetryAfter time.Duration
+}
+
+// ShouldRetry 实现 RetryPolicy
+func (r RetryConfig) ShouldRetry(info RetryInfo) (time.Duration, bool) {
+	if info.Attempt >= r.attempts() {
+		return 0, false
+	}
+	if !r.RetryNonIdempotent && !isIdempotent(info.Method) {
+		return 0, false
+	}
+	if info.Err == nil && !r.retryableStatus(info.Status) {
+		return 0, false
+	}
+	wait := r.backoff(info.Attempt - 1)
+	if retryAfter, ok := parseRetryAfter(info.Header); ok {
+		maxRetryAfter := r.MaxRetryAfter
+		if maxRetryAfter <= 0 {
+			maxRetryAfter = defaultMaxRetryAfter
+		}
+		if retryAfter > maxRetryAfter {
+			return 0, false
+		}
+		wait = max(wait, retryAfter)
+	}
+	return wait, true
+}
+
+func (r RetryConfig) attempts() int {
+	return max(r.MaxAttempts, 1)
+}
+
+func (r RetryConfig) retryableStatus(status int) bool {
+	codes := r.RetryableStatus
+	if len(codes) == 0 {
+		codes = defaultRetryableStatus
+	}
+	return slices.Contains(codes, status)
+}
+
+// backoff 第 retry 次重试（从 0 开始）前的等待时间，指数增长并在 [d/2, d] 内随机抖动
+func (r RetryConfig) backoff(retry int) time.Duration {
+	initial := r.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	maxBackoff := r.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	d := initial << min(retry, 30)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// sleepContext 等待 d，ctx 结束时提前返回 ctx 的错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isIdempotent 按 RFC 9110 判断方法是否幂等
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter 解析秒数或 HTTP 日期格式的 Retry-After
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnknownEndpoint = errors.New("unknown endpoint")
+
+// Endpoint 下游接口定义
+type Endpoint struct {
+	Name string
+	// Method 默认 GET
+	Method string
+	// Path 相对 Service 基础地址的路径模板，例如 /users/{id}
+	Path string
+	// Headers 该接口的默认请求头，覆盖 Service 的默认请求头
+	Headers map[string]string
+	// Timeout 该接口每次尝试的超时，0 表示使用客户端默认超时，可被 WithTimeout 覆盖
+	Timeout time.Duration
+}
+
+// CallParams Service.Call 的参数
+type CallParams struct {
+	// Path 路径模板参数，值会被转义
+	Path map[string]string
+	// Query 查询参数
+	Query map[string]string
+	// Headers 本次调用的请求头，覆盖接口的默认请求头
+	Headers map[string]string
+	// Body 以 JSON 发送的请求体，为 nil 时不发送
+	Body any
+}
+
+// Service 一个下游服务的接口集合，接口注册一次后按名称调用，dal 日志以 endpoint 字段记录 "服务名.接口名"
+type Service struct {
+	name    string
+	client  *DalHttpClient
+	baseURL string
+	headers map[string]string
+
+	mu        sync.RWMutex
+	endpoints map[string]Endpoint
+}
+
+// NewService baseURL 为下游服务的基础地址，headers 为所有接口的默认请求头
+func NewService(name string, client *DalHttpClient, baseURL string, headers map[string]string, endpoints ...Endpoint) *Service {
+	s := &Service{
+		name:      name,
+		client:    client,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		headers:   headers,
+		endpoints: make(map[string]Endpoint, len(endpoints)),
+	}
+	for _, e := range endpoints {
+		s.Register(e)
+	}
+	return s
+}
+
+// Register 注册接口，同名接口会被覆盖
+func (s *Service) Register(e Endpoint) {
+	if e.Method == "" {
+		e.Method = http.MethodGet
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints[e.Name] = e
+}
+
+// Call 调用名为 name 的接口并将 JSON 响应解码到 resp。接口未注册时返回 ErrUnknownEndpoint，非 2xx 响应返回 *HTTPError
+func (s *Service) Call(ctx context.Context, name string, params CallParams, resp any, opts ...CallOption) error {
+	s.mu.RLock()
+	e, ok := s.endpoints[name]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s.%s", ErrUnknownEndpoint, s.name, name)
+	}
+	path, err := expandPath(e.Path, params.Path)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", s.name, name, err)
+	}
+	headers := make(map[string]string, len(s.headers)+len(e.Headers)+len(params.Headers))
+	for _, h := range []map[string]string{s.headers, e.Headers, params.Headers} {
+		for k, v := range h {
+			headers[k] = v
+		}
+	}
+	callOpts := make([]CallOption, 0, len(opts)+2)
+	callOpts = append(callOpts, withEndpoint(s.name+"."+name))
+	if e.Timeout > 0 {
+		callOpts = append(callOpts, WithTimeout(e.Timeout))
+	}
+	callOpts = append(callOpts, opts...)
+	return s.client.sendJson(ctx, "Service.Call", e.Method, withQuery(s.baseURL+path, params.Query), headers, params.Body, resp, callOpts)
+}
+
+// expandPath 替换路径模板中的 {name}，缺少参数时返回错误
+func expandPath(tmpl string, params map[string]string) (string, error) {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			sb.WriteString(tmpl)
+			return sb.String(), nil
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("invalid path template %q", tmpl)
+		}
+		end += start
+		key := tmpl[start+1 : end]
+		v, ok := params[key]
+		if !ok {
+			return "", fmt.Errorf("missing path param %q", key)
+		}
+		sb.WriteString(tmpl[:start])
+		sb.WriteString(url.PathEscape(v))
+		tmpl = tmpl[end+1:]
+	}
+}
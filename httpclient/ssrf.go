@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+var (
+	// ErrHostNotAllowed 请求或重定向的目标 host 不在 WithAllowedHosts 白名单中
+	ErrHostNotAllowed = errors.New("host not allowed")
+	// ErrBlockedAddress 目标地址解析为内网、回环或链路本地地址
+	ErrBlockedAddress = errors.New("blocked address")
+)
+
+// cgnatPrefix 运营商级 NAT 地址段
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// WithAllowedHosts 只允许请求白名单中的 host（不含端口，不区分大小写），"*.example.com" 匹配所有子域名。
+// 重定向目标同样校验，不在白名单中时返回 ErrHostNotAllowed
+func WithAllowedHosts(hosts ...string) Option {
+	return func(o *options) {
+		for _, h := range hosts {
+			o.allowedHosts = append(o.allowedHosts, strings.ToLower(h))
+		}
+	}
+}
+
+// WithBlockPrivateIPs 拒绝连接内网、回环、链路本地、组播与未指定地址，按实际连接的 IP 校验，可防止 DNS rebinding。
+// 开启后不使用环境变量中的代理；WithTransport 替换 Transport 时不生效
+func WithBlockPrivateIPs() Option {
+	return func(o *options) {
+		o.blockPrivateIPs = true
+	}
+}
+
+// hostAllowed 未配置白名单时允许所有 host
+func (o options) hostAllowed(host string) bool {
+	if len(o.allowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range o.allowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRedirect 校验重定向目标，其余行为与 http.Client 默认一致
+func (o options) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if !o.hostAllowed(req.URL.Hostname()) {
+		return fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Hostname())
+	}
+	return nil
+}
+
+// blockPrivateControl 作为 net.Dialer.Control，在建立连接前校验解析后的 IP
+func blockPrivateControl(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	addr := addrPort.Addr().Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() || cgnatPrefix.Contains(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Package httptesting 提供 DalHttpClient 的测试工具：按请求匹配返回预设响应的 Mock，以及录制与回放真实响应的 Recorder。
+// 通过 httpclient.WithTransport 注入
+package httptesting
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
+
+// ErrNoStub 请求没有匹配的预设响应
+var ErrNoStub = errors.New("httptesting: no stub matched request")
+
+// Matcher 判断请求是否匹配
+type Matcher func(req *http.Request) bool
+
+// MatchRequest method 为空时匹配任意方法；url 与请求 URL（不含查询参数）完全相同，或以 * 结尾时按前缀匹配
+func MatchRequest(method string, url string) Matcher {
+	return func(req *http.Request) bool {
+		if method != "" && req.Method != method {
+			return false
+		}
+		target := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+		if prefix, ok := strings.CutSuffix(url, "*"); ok {
+			return strings.HasPrefix(target, prefix)
+		}
+		return target == url
+	}
+}
+
+// Call 一次被 Mock 处理的请求，Body 为读取后的请求体
+type Call struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Stub 预设响应
+type Stub struct {
+	matcher Matcher
+	status  int
+	header  http.Header
+	body    []byte
+	err     error
+	times   int
+	calls   int
+}
+
+// Reply 返回 status 与 body
+func (s *Stub) Reply(status int, body string) *Stub {
+	s.status = status
+	s.body = []byte(body)
+	return s
+}
+
+// ReplyJSON 返回 status 与 v 的 JSON 编码，并设置 Content-Type
+func (s *Stub) ReplyJSON(status int, v any) *Stub {
+	body, err := sonic.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httptesting: marshal reply: %v", err))
+	}
+	s.status = status
+	s.body = body
+	s.header.Set("Content-Type", "application/json")
+	return s
+}
+
+// ReplyError 返回网络错误
+func (s *Stub) ReplyError(err error) *Stub {
+	s.err = err
+	return s
+}
+
+// WithHeader 设置响应头
+func (s *Stub) WithHeader(k string, v string) *Stub {
+	s.header.Set(k, v)
+	return s
+}
+
+// Times 只匹配 n 次，之后交给后续的 Stub，默认不限次数
+func (s *Stub) Times(n int) *Stub {
+	s.times = n
+	return s
+}
+
+// Mock 按添加顺序匹配 Stub 返回预设响应的 http.RoundTripper，并记录所有请求
+type Mock struct {
+	mu    sync.Mutex
+	stubs []*Stub
+	calls []Call
+}
+
+func NewMock() *Mock {
+	return &Mock{}
+}
+
+// On 添加匹配 method 与 url 的 Stub，规则同 MatchRequest
+func (m *Mock) On(method string, url string) *Stub {
+	return m.OnMatch(MatchRequest(method, url))
+}
+
+// OnMatch 添加自定义匹配的 Stub，默认返回 200 与空响应体
+func (m *Mock) OnMatch(matcher Matcher) *Stub {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := &Stub{matcher: matcher, status: http.StatusOK, header: make(http.Header)}
+	m.stubs = append(m.stubs, s)
+	return s
+}
+
+// RoundTrip 实现 http.RoundTripper，没有匹配的 Stub 时返回 ErrNoStub
+func (m *Mock) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+	m.mu.Lock()
+	m.calls = append(m.calls, Call{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: body})
+	var stub *Stub
+	for _, s := range m.stubs {
+		if (s.times <= 0 || s.calls < s.times) && s.matcher(req) {
+			s.calls++
+			stub = s
+			break
+		}
+	}
+	m.mu.Unlock()
+	if stub == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoStub, req.Method, req.URL)
+	}
+	if stub.err != nil {
+		return nil, stub.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", stub.status, http.StatusText(stub.status)),
+		StatusCode:    stub.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        stub.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(stub.body)),
+		ContentLength: int64(len(stub.body)),
+		Request:       req,
+	}, nil
+}
+
+// Calls 返回已处理的请求
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// AssertCalled 断言存在 n 次匹配 method 与 url 的请求
+func (m *Mock) AssertCalled(t testing.TB, method string, url string, n int) {
+	t.Helper()
+	matcher := MatchRequest(method, url)
+	count := 0
+	for _, call := range m.Calls() {
+		req, err := http.NewRequest(call.Method, call.URL, nil)
+		if err == nil && matcher(req) {
+			count++
+		}
+	}
+	if count != n {
+		t.Errorf("httptesting: expected %d calls to %s %s, got %d", n, method, url, count)
+	}
+}
+
+// AssertExpectations 断言每个 Stub 都至少匹配过一次，设置了 Times 的 Stub 恰好匹配 n 次
+func (m *Mock) AssertExpectations(t testing.TB) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.stubs {
+		if s.calls == 0 || (s.times > 0 && s.calls != s.times) {
+			t.Errorf("httptesting: stub #%d matched %d times", i, s.calls)
+		}
+	}
+}
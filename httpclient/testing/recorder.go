@@ -0,0 +1,144 @@
+package httptesting
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bytedance/sonic"
+)
+
+// Mode Recorder 的工作模式
+type Mode int
+
+const (
+	// ModeReplay 只从 fixture 返回响应，fixture 不存在时返回错误
+	ModeReplay Mode = iota
+	// ModeRecord 转发到真实下游并把响应写入 fixture
+	ModeRecord
+	// ModeReplayOrRecord fixture 存在时回放，否则录制
+	ModeReplayOrRecord
+)
+
+// fixture 录制的一次请求与响应，按 method、URL 与请求体的摘要命名
+type fixture struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Body     string      `json:"body,omitempty"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header,omitempty"`
+	Response string      `json:"response"`
+}
+
+// Recorder 录制与回放响应的 http.RoundTripper，fixture 以 JSON 文件保存在 dir 中，只适用于文本请求体与响应体
+type Recorder struct {
+	dir  string
+	mode Mode
+	next http.RoundTripper
+}
+
+// NewRecorder next 为录制时转发的 RoundTripper，为 nil 时使用 http.DefaultTransport
+func NewRecorder(dir string, mode Mode, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, mode: mode, next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+	path := r.fixturePath(req, body)
+	if r.mode != ModeRecord {
+		f, err := readFixture(path)
+		if err == nil {
+			return f.response(req), nil
+		}
+		if r.mode == ModeReplay || !os.IsNotExist(err) {
+			return nil, fmt.Errorf("httptesting: replay %s %s: %w", req.Method, req.URL, err)
+		}
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	resp, err := r.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	f := fixture{
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Body:     string(body),
+		Status:   resp.StatusCode,
+		Header:   resp.Header,
+		Response: string(respBody),
+	}
+	if err = writeFixture(path, f); err != nil {
+		return nil, err
+	}
+	return f.response(req), nil
+}
+
+func (r *Recorder) fixturePath(req *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, req.Method+" "+req.URL.String()+"\n")
+	_, _ = h.Write(body)
+	return filepath.Join(r.dir, hex.EncodeToString(h.Sum(nil))[:16]+".json")
+}
+
+func readFixture(path string) (fixture, error) {
+	var f fixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return f, err
+	}
+	err = sonic.Unmarshal(data, &f)
+	return f, err
+}
+
+func writeFixture(path string, f fixture) error {
+	data, err := sonic.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (f fixture) response(req *http.Request) *http.Response {
+	header := f.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(f.Response))),
+		ContentLength: int64(len(f.Response)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig 出站 TLS 配置，零值表示使用默认配置
+type TLSConfig struct {
+	// Certificates mTLS 客户端证书，可由 tls.LoadX509KeyPair 加载
+	Certificates []tls.Certificate
+	// RootCAs 校验服务端证书的 CA，为 nil 时使用系统 CA，可由 LoadCertPool 加载
+	RootCAs *x509.CertPool
+	// ServerName 校验服务端证书时使用的域名，默认取请求的 host
+	ServerName string
+	// InsecureSkipVerify 跳过服务端证书校验，仅用于测试环境
+	InsecureSkipVerify bool
+	// MinVersion 最低 TLS 版本，默认 TLS 1.2
+	MinVersion uint16
+}
+
+// build 零值时返回 nil，保留 Transport 的默认 TLS 配置
+func (t TLSConfig) build() *tls.Config {
+	if len(t.Certificates) == 0 && t.RootCAs == nil && t.ServerName == "" && !t.InsecureSkipVerify && t.MinVersion == 0 {
+		return nil
+	}
+	minVersion := t.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	return &tls.Config{
+		Certificates:       t.Certificates,
+		RootCAs:            t.RootCAs,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		MinVersion:         minVersion,
+	}
+}
+
+// LoadCertPool 从 PEM 文件加载 CA 证书，不包含系统 CA
+func LoadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", file)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,43 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/TomWu-Alchemi/project-framework/httpclient"
+
+// startClientSpan 为一次请求创建 client span，并按全局 TextMapPropagator 注入 traceparent 等请求头。
+// 未设置全局 TracerProvider 与 Propagator 时均为 no-op
+func startClientSpan(ctx context.Context, req *http.Request) trace.Span {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.Redacted()),
+			attribute.String("server.address", req.URL.Host),
+		),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return span
+}
+
+// endClientSpan 记录状态码与错误并结束 span，5xx 视为错误
+func endClientSpan(span trace.Span, status int, err error) {
+	if status > 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
@@ -0,0 +1,252 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+	"github.com/bytedance/sonic"
+)
+
+var (
+	ErrWebhookQueueFull    = errors.New("webhook queue full")
+	ErrWebhookSenderClosed = errors.New("webhook sender closed")
+)
+
+const (
+	defaultWebhookWorkers        = 4
+	defaultWebhookQueueSize      = 1024
+	defaultWebhookInitialBackoff = time.Second
+	defaultWebhookMaxBackoff     = 5 * time.Minute
+	defaultWebhookMaxAge         = 24 * time.Hour
+	webhookIDHeader              = "X-Webhook-Id"
+)
+
+// WebhookConfig WebhookSender 配置
+type WebhookConfig struct {
+	// Signer Secret 不为空时对每次投递签名，签名方式同 WithHMACSigning
+	Signer HMACSigner
+	// Workers 并发投递的协程数，默认 4
+	Workers int
+	// QueueSize 待投递队列长度，默认 1024，队列满时 Send 返回 ErrWebhookQueueFull
+	QueueSize int
+	// InitialBackoff 首次重新投递前的等待时间，之后每次翻倍，默认 1s
+	InitialBackoff time.Duration
+	// MaxBackoff 单次等待时间上限，默认 5 分钟
+	MaxBackoff time.Duration
+	// MaxAge 自 Send 起超过该时长仍未投递成功时放弃，默认 24 小时
+	MaxAge time.Duration
+	// OnGiveUp 放弃投递时回调，可用于写入死信存储
+	OnGiveUp func(d WebhookDelivery, err error)
+}
+
+// WebhookDelivery 一次 webhook 投递
+type WebhookDelivery struct {
+	// ID 投递 ID，每次投递都以 X-Webhook-Id 请求头发送，接收方可据此去重
+	ID        string
+	URL       string
+	Headers   map[string]string
+	Body      []byte
+	CreatedAt time.Time
+	// Attempts 已尝试的次数
+	Attempts int
+}
+
+// WebhookSender 异步投递 webhook：签名、失败后指数退避重新投递，并按目标 host 记录投递监控。
+// 非 2xx 响应与网络错误视为失败
+type WebhookSender struct {
+	client  *DalHttpClient
+	conf    WebhookConfig
+	queue   chan *WebhookDelivery
+	workers sync.WaitGroup
+	baseCtx context.Context
+	cancel  context.CancelFunc
+
+	// mu 保护 closed 与 timers
+	mu     sync.Mutex
+	closed bool
+	timers map[*WebhookDelivery]*time.Timer
+}
+
+func NewWebhookSender(client *DalHttpClient, conf WebhookConfig) *WebhookSender {
+	if conf.Workers <= 0 {
+		conf.Workers = defaultWebhookWorkers
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = defaultWebhookQueueSize
+	}
+	if conf.InitialBackoff <= 0 {
+		conf.InitialBackoff = defaultWebhookInitialBackoff
+	}
+	if conf.MaxBackoff <= 0 {
+		conf.MaxBackoff = defaultWebhookMaxBackoff
+	}
+	if conf.MaxAge <= 0 {
+		conf.MaxAge = defaultWebhookMaxAge
+	}
+	if conf.Signer.Secret != "" {
+		conf.Signer = conf.Signer.withDefaults()
+	}
+	s := &WebhookSender{
+		client: client,
+		conf:   conf,
+		queue:  make(chan *WebhookDelivery, conf.QueueSize),
+		timers: make(map[*WebhookDelivery]*time.Timer),
+	}
+	s.baseCtx, s.cancel = context.WithCancel(context.Background())
+	s.workers.Add(conf.Workers)
+	for i := 0; i < conf.Workers; i++ {
+		go s.run()
+	}
+	return s
+}
+
+// Send 以 JSON 编码 payload 并加入投递队列，返回投递 ID
+func (s *WebhookSender) Send(url string, payload any, headers map[string]string) (string, error) {
+	body, err := sonic.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	d := &WebhookDelivery{
+		ID:        newIdempotencyKey(),
+		URL:       url,
+		Headers:   headers,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return "", ErrWebhookSenderClosed
+	}
+	select {
+	case s.queue <- d:
+		return d.ID, nil
+	default:
+		return "", ErrWebhookQueueFull
+	}
+}
+
+func (s *WebhookSender) run() {
+	defer s.workers.Done()
+	for d := range s.queue {
+		s.deliver(d)
+	}
+}
+
+func (s *WebhookSender) deliver(d *WebhookDelivery) {
+	d.Attempts++
+	destination := d.URL
+	if u, err := url.Parse(d.URL); err == nil {
+		destination = u.Host
+	}
+	start := time.Now()
+	err := s.attempt(d)
+	if err == nil {
+		metrics.WebhookDeliveryMetric(destination, metrics.WebhookDelivered, time.Since(start))
+		return
+	}
+	wait := RetryConfig{InitialBackoff: s.conf.InitialBackoff, MaxBackoff: s.conf.MaxBackoff}.backoff(d.Attempts - 1)
+	if time.Since(d.CreatedAt)+wait > s.conf.MaxAge {
+		metrics.WebhookDeliveryMetric(destination, metrics.WebhookFailed, time.Since(start))
+		s.giveUp(d, err)
+		return
+	}
+	metrics.WebhookDeliveryMetric(destination, metrics.WebhookRetry, time.Since(start))
+	s.retryAfter(d, wait, err)
+}
+
+func (s *WebhookSender) attempt(d *WebhookDelivery) error {
+	headers := make(map[string]string, len(d.Headers)+5)
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers["Content-Type"] = "application/json"
+	headers[webhookIDHeader] = d.ID
+	if s.conf.Signer.Secret != "" {
+		u, err := url.Parse(d.URL)
+		if err != nil {
+			return err
+		}
+		for k, v := range s.conf.Signer.signHeaders(http.MethodPost, u.RequestURI(), d.Body) {
+			headers[k] = v
+		}
+	}
+	resp, err := s.client.do(s.baseCtx, dalRequest{
+		name:    "Webhook",
+		method:  http.MethodPost,
+		url:     d.URL,
+		headers: headers,
+		body:    d.Body,
+		timeout: s.client.timeout,
+	})
+	if err != nil {
+		return err
+	}
+	if !isSuccess(resp.status) {
+		return newHTTPError(resp)
+	}
+	return nil
+}
+
+// retryAfter wait 后重新加入队列，Sender 已关闭时放弃
+func (s *WebhookSender) retryAfter(d *WebhookDelivery, wait time.Duration, lastErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		s.giveUp(d, lastErr)
+		return
+	}
+	s.timers[d] = time.AfterFunc(wait, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.timers[d]; !ok {
+			return
+		}
+		delete(s.timers, d)
+		select {
+		case s.queue <- d:
+		default:
+			s.giveUp(d, ErrWebhookQueueFull)
+		}
+	})
+}
+
+func (s *WebhookSender) giveUp(d *WebhookDelivery, err error) {
+	if s.conf.OnGiveUp != nil {
+		s.conf.OnGiveUp(*d, err)
+	}
+}
+
+// Close 不再接受新的投递，等待队列中的投递完成；等待重新投递的任务立即放弃并回调 OnGiveUp。
+// ctx 结束时取消执行中的投递并返回 ctx 的错误
+func (s *WebhookSender) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		for d, t := range s.timers {
+			t.Stop()
+			delete(s.timers, d)
+			s.giveUp(d, ErrWebhookSenderClosed)
+		}
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultAlertFrames = 5
+
+// PanicEvent is the summary of a recovered panic handed to a PanicAlerter.
+type PanicEvent struct {
+	Route  string
+	Method string
+	Error  string
+	Frames []string
+	Time   time.Time
+}
+
+// PanicAlerter is notified by the recovery middleware whenever a panic is recovered.
+// Implementations must not block the request goroutine.
+type PanicAlerter interface {
+	Alert(event PanicEvent)
+}
+
+// WebhookKind selects the message format of the webhook.
+type WebhookKind int
+
+const (
+	WebhookFeishu WebhookKind = iota
+	WebhookDingTalk
+	WebhookSlack
+)
+
+// WebhookAlerterConfig is config setting for NewWebhookAlerter
+type WebhookAlerterConfig struct {
+	URL  string
+	Kind WebhookKind
+	// Interval is the minimum time between two alerts of the same route.
+	// Alerts raised within the interval are dropped.
+	Interval time.Duration
+	// Timeout of the webhook request, default 3s.
+	Timeout time.Duration
+}
+
+// WebhookAlerter posts panic summaries to a Feishu/DingTalk/Slack robot webhook.
+type WebhookAlerter struct {
+	conf   WebhookAlerterConfig
+	client *http.Client
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewWebhookAlerter returns a PanicAlerter posting to conf.URL.
+func NewWebhookAlerter(conf WebhookAlerterConfig) *WebhookAlerter {
+	if conf.Timeout <= 0 {
+		conf.Timeout = 3 * time.Second
+	}
+	return &WebhookAlerter{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout},
+		last:   make(map[string]time.Time),
+	}
+}
+
+// Alert sends the event asynchronously unless the route has been alerted within Interval.
+func (a *WebhookAlerter) Alert(event PanicEvent) {
+	if !a.allow(event.Route, event.Time) {
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				Error(fmt.Sprintf("panic in panic alerter: %v", r))
+			}
+		}()
+		if err := a.send(event); err != nil {
+			Error("send panic alert failed: " + err.Error())
+		}
+	}()
+}
+
+func (a *WebhookAlerter) allow(route string, now time.Time) bool {
+	if a.conf.Interval <= 0 {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if last, ok := a.last[route]; ok && now.Sub(last) < a.conf.Interval {
+		return false
+	}
+	a.last[route] = now
+	return true
+}
+
+func (a *WebhookAlerter) send(event PanicEvent) error {
+	body, err := sonic.Marshal(a.payload(formatPanicEvent(event)))
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Post(a.conf.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook status code:%d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (a *WebhookAlerter) payload(text string) any {
+	switch a.conf.Kind {
+	case WebhookDingTalk:
+		return map[string]any{"msgtype": "text", "text": map[string]string{"content": text}}
+	case WebhookSlack:
+		return map[string]any{"text": text}
+	default:
+		return map[string]any{"msg_type": "text", "content": map[string]string{"text": text}}
+	}
+}
+
+func formatPanicEvent(event PanicEvent) string {
+	b := strings.Builder{}
+	b.WriteString("[Panic] ")
+	b.WriteString(event.Method)
+	b.WriteString(" ")
+	b.WriteString(event.Route)
+	b.WriteString("\ntime: ")
+	b.WriteString(inLogLocation(event.Time).Format(time.DateTime))
+	b.WriteString("\nerror: ")
+	b.WriteString(event.Error)
+	for _, frame := range event.Frames {
+		b.WriteString("\n  ")
+		b.WriteString(frame)
+	}
+	return b.String()
+}
+
+func newPanicEvent(c *gin.Context, err interface{}) PanicEvent {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	return PanicEvent{
+		Route:  route,
+		Method: c.Request.Method,
+		Error:  fmt.Sprint(err),
+		Frames: panicFrames(defaultAlertFrames),
+		Time:   time.Now(),
+	}
+}
+
+// panicFrames returns the top n frames of the panicking goroutine, skipping runtime internals.
+func panicFrames(n int) []string {
+	pcs := make([]uintptr, 32)
+	count := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:count])
+	res := make([]string, 0, n)
+	for len(res) < n {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") && !strings.Contains(frame.Function, "CustomRecoveryWithZap") {
+			res = append(res, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return res
+}
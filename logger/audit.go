@@ -0,0 +1,26 @@
+package logger
+
+import "go.uber.org/zap"
+
+// AuditActor 审计事件的操作人
+func AuditActor(id string) zap.Field {
+	return zap.String("actor", id)
+}
+
+// AuditTarget 审计事件的操作对象
+func AuditTarget(target string) zap.Field {
+	return zap.String("target", target)
+}
+
+// AuditIP 操作来源 IP
+func AuditIP(ip string) zap.Field {
+	return zap.String("ip", ip)
+}
+
+// AuditResult 操作结果
+func AuditResult(success bool) zap.Field {
+	if success {
+		return zap.String("result", "success")
+	}
+	return zap.String("result", "failure")
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const fingerprintFrames = 3
+
+var fingerprintVariable = regexp.MustCompile(`0x[0-9a-fA-F]+|[0-9]+`)
+
+// fingerprintCore 为 Error 及以上级别的日志附加 fingerprint 字段，
+// 同类错误（错误类型 + 栈顶函数，无堆栈时为错误类型 + 消息模板）得到相同的指纹，便于下游聚合与告警去重
+type fingerprintCore struct {
+	zapcore.Core
+	fields []zapcore.Field
+}
+
+func newFingerprintCore(core zapcore.Core) zapcore.Core {
+	return &fingerprintCore{Core: core}
+}
+
+func (c *fingerprintCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fingerprintCore{
+		Core:   c.Core.With(fields),
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *fingerprintCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fingerprintCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level >= zapcore.ErrorLevel {
+		all := append(c.fields[:len(c.fields):len(c.fields)], fields...)
+		fields = append(fields, zap.String("fingerprint", fingerprint(ent, all)))
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func fingerprint(ent zapcore.Entry, fields []zapcore.Field) string {
+	var errType, errMsg, stack string
+	for _, f := range fields {
+		switch f.Key {
+		case "error":
+			errType, errMsg = errorFieldInfo(f)
+		case "stack":
+			if f.Type == zapcore.StringType {
+				stack = f.String
+			}
+		}
+	}
+	if ent.Stack != "" {
+		stack = ent.Stack
+	}
+	if stack == "" && strings.Contains(ent.Message, "\n\t") {
+		// StackedError 将 %+v 格式的堆栈写在消息中
+		stack = ent.Message
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(errType))
+	if frames := stackFunctions(stack, fingerprintFrames); len(frames) > 0 {
+		for _, frame := range frames {
+			h.Write([]byte{'\n'})
+			h.Write([]byte(frame))
+		}
+	} else {
+		if errMsg == "" {
+			errMsg = ent.Message
+		}
+		h.Write([]byte{'\n'})
+		h.Write([]byte(fingerprintVariable.ReplaceAllString(errMsg, "?")))
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+func errorFieldInfo(f zapcore.Field) (string, string) {
+	switch f.Type {
+	case zapcore.StringType:
+		return "string", f.String
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return fmt.Sprintf("%T", err), err.Error()
+		}
+	case zapcore.StringerType, zapcore.ReflectType:
+		return fmt.Sprintf("%T", f.Interface), fmt.Sprint(f.Interface)
+	}
+	return "", ""
+}
+
+// stackFunctions 从 debug.Stack 或 pkg/errors 的 %+v 堆栈中取出前 n 个非 runtime 函数名，忽略行号以保证跨版本稳定
+func stackFunctions(stack string, n int) []string {
+	lines := strings.Split(stack, "\n")
+	res := make([]string, 0, n)
+	for i := 0; i+1 < len(lines) && len(res) < n; i++ {
+		line := lines[i]
+		if line == "" || strings.HasPrefix(line, "\t") || !strings.HasPrefix(lines[i+1], "\t") {
+			continue
+		}
+		if idx := strings.LastIndex(line, "("); idx > 0 {
+			line = line[:idx]
+		}
+		if strings.HasPrefix(line, "runtime.") || strings.HasPrefix(line, "runtime/debug.") ||
+			strings.HasPrefix(line, "panic") || strings.HasPrefix(line, "created by ") {
+			continue
+		}
+		res = append(res, line)
+	}
+	return res
+}
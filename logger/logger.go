@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime/debug"
 	"time"
 
+	"github.com/TomWu-Alchemi/project-framework/metrics"
 	"github.com/natefinch/lumberjack"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -20,12 +20,124 @@ var recoveryLog *zap.Logger
 
 var dalLog *zap.Logger
 
+var auditLog *zap.Logger
+
+// logLocation 日志时间所用时区，为 nil 时使用本地时区
+var logLocation *time.Location
+
+// LoggerConfig 日志初始化配置，每个日志通道可单独配置文件与切分计划。
+// Info 与 Error 通道共用一个 logger，调用位置与堆栈配置以 Info 通道为准
+type LoggerConfig struct {
+	Info   ChannelConfig
+	Error  ChannelConfig
+	Access ChannelConfig
+	Panic  ChannelConfig
+	Dal    ChannelConfig
+	Audit  ChannelConfig
+	// Location 日志时间戳、切分计划以及访问日志 time 字段使用的时区，为 nil 时使用本地时区
+	Location *time.Location
+	// Fingerprint 为 error 与 panic 日志附加 fingerprint 字段
+	Fingerprint bool
+	// LogMetrics 按级别与通道统计 Warn 及以上级别的日志条数（log_entries_total）
+	LogMetrics bool
+}
+
+// ChannelConfig 单个日志通道的配置
+type ChannelConfig struct {
+	Filename   string
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+	// Rotation 强制切分计划，为 nil 时仅按 MaxSize 切分
+	Rotation RotationSchedule
+	// Caller 是否记录调用位置
+	Caller bool
+	// CallerSkip 记录调用位置时额外跳过的栈帧数
+	CallerSkip int
+	// Stacktrace 达到该级别的日志附带堆栈，为 nil 时不附带
+	Stacktrace zapcore.LevelEnabler
+}
+
+func (c ChannelConfig) options() []zap.Option {
+	var opts []zap.Option
+	if c.Caller {
+		opts = append(opts, zap.AddCaller())
+	}
+	if c.CallerSkip != 0 {
+		opts = append(opts, zap.AddCallerSkip(c.CallerSkip))
+	}
+	if c.Stacktrace != nil {
+		opts = append(opts, zap.AddStacktrace(c.Stacktrace))
+	}
+	return opts
+}
+
+// DefaultLoggerConfig 默认配置：所有通道按小时切分
+func DefaultLoggerConfig() LoggerConfig {
+	return LoggerConfig{
+		Info: ChannelConfig{
+			Filename:   "./log/info/info.log",
+			MaxSize:    30,
+			MaxAge:     7,
+			MaxBackups: 169,
+			Rotation:   HourlyRotation(),
+			Caller:     true,
+			// 跳过 Info/Warn/Error 等包装函数，记录真实调用位置
+			CallerSkip: 1,
+		},
+		Error: ChannelConfig{
+			Filename:   "./log/error/error.log",
+			MaxSize:    30,
+			MaxAge:     14,
+			MaxBackups: 420,
+			Rotation:   HourlyRotation(),
+		},
+		Access: ChannelConfig{
+			Filename:   "./log/access/access.log",
+			MaxSize:    30,
+			MaxAge:     7,
+			MaxBackups: 169,
+			Rotation:   HourlyRotation(),
+		},
+		Panic: ChannelConfig{
+			Filename:   "./log/panic/panic.log",
+			MaxSize:    30,
+			MaxAge:     14,
+			MaxBackups: 420,
+			Rotation:   HourlyRotation(),
+		},
+		Dal: ChannelConfig{
+			Filename:   "./log/dal/dal.log",
+			MaxSize:    30,
+			MaxAge:     7,
+			MaxBackups: 169,
+			Rotation:   HourlyRotation(),
+		},
+		// 审计日志保留 180 天，按天切分
+		Audit: ChannelConfig{
+			Filename:   "./log/audit/audit.log",
+			MaxSize:    30,
+			MaxAge:     180,
+			MaxBackups: 0,
+			Rotation:   DailyRotation(0, 0),
+		},
+	}
+}
+
 func InitLogger() {
+	InitLoggerWithConfig(DefaultLoggerConfig())
+}
+
+func InitLoggerWithConfig(conf LoggerConfig) {
+	// 重复初始化时先停止上一次的切分任务
+	StopRotation()
+	logLocation = conf.Location
+
 	var coreArr []zapcore.Core
 	// 编码器
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-		enc.AppendString(t.Format(time.DateTime))
+		enc.AppendString(inLogLocation(t).Format(time.DateTime))
 	}
 	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
 	encoder := zapcore.NewConsoleEncoder(encoderConfig)
@@ -37,90 +149,66 @@ func InitLogger() {
 		return lvl >= zap.WarnLevel
 	})
 
-	infoLoggerWriter := &lumberjack.Logger{
-		Filename:   getAbsPath("./log/info/info.log"),
-		MaxSize:    30,
-		MaxAge:     7,
-		MaxBackups: 169,
-		LocalTime:  true,
-		Compress:   false,
-	}
+	infoLoggerWriter := newFileWriter(conf.Info)
 	infoFileWriteSyncer := zapcore.AddSync(infoLoggerWriter)
 	infoFileCore := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(infoFileWriteSyncer, zapcore.AddSync(os.Stdout)), lowPriority)
 
-	errorLoggerWriter := &lumberjack.Logger{
-		Filename:   getAbsPath("./log/error/error.log"),
-		MaxSize:    30,
-		MaxAge:     14,
-		MaxBackups: 420,
-		LocalTime:  true,
-		Compress:   false,
-	}
+	errorLoggerWriter := newFileWriter(conf.Error)
 	errorFileWriteSyncer := zapcore.AddSync(errorLoggerWriter)
 	errorFileCore := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(errorFileWriteSyncer, zapcore.AddSync(os.Stdout)), highPriority)
+	if conf.Fingerprint {
+		errorFileCore = newFingerprintCore(errorFileCore)
+	}
+	if conf.LogMetrics {
+		errorFileCore = withLogMetrics(errorFileCore, "error")
+	}
 
 	coreArr = append(coreArr, infoFileCore, errorFileCore)
-	log = zap.New(zapcore.NewTee(coreArr...), zap.AddCaller()).Sugar()
+	log = zap.New(zapcore.NewTee(coreArr...), conf.Info.options()...).Sugar()
 
-	accessLoggerWriter := &lumberjack.Logger{
-		Filename:   getAbsPath("./log/access/access.log"),
-		MaxSize:    30,
-		MaxAge:     7,
-		MaxBackups: 169,
-		LocalTime:  true,
-		Compress:   false,
-	}
+	accessLoggerWriter := newFileWriter(conf.Access)
 	accessFileWriteSyncer := zapcore.AddSync(accessLoggerWriter)
 	accessFileCore := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(accessFileWriteSyncer, zapcore.AddSync(os.Stdout)), zap.InfoLevel)
-	accessLog = zap.New(accessFileCore)
-
-	panicLoggerWriter := &lumberjack.Logger{
-		Filename:   getAbsPath("./log/panic/panic.log"),
-		MaxSize:    30,
-		MaxAge:     14,
-		MaxBackups: 420,
-		LocalTime:  true,
-		Compress:   false,
+	if conf.LogMetrics {
+		accessFileCore = withLogMetrics(accessFileCore, "access")
 	}
+	accessLog = zap.New(accessFileCore, conf.Access.options()...)
+
+	panicLoggerWriter := newFileWriter(conf.Panic)
 	panicFileWriteSyncer := zapcore.AddSync(panicLoggerWriter)
 	panicFileCore := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(panicFileWriteSyncer, zapcore.AddSync(os.Stdout)), zap.InfoLevel)
-	recoveryLog = zap.New(panicFileCore)
-
-	dataFileLoggerWriter := &lumberjack.Logger{
-		Filename:   getAbsPath("./log/dal/dal.log"),
-		MaxSize:    30,
-		MaxAge:     7,
-		MaxBackups: 169,
-		LocalTime:  true,
-		Compress:   false,
+	if conf.Fingerprint {
+		panicFileCore = newFingerprintCore(panicFileCore)
+	}
+	if conf.LogMetrics {
+		panicFileCore = withLogMetrics(panicFileCore, "panic")
 	}
+	recoveryLog = zap.New(panicFileCore, conf.Panic.options()...)
+
+	dataFileLoggerWriter := newFileWriter(conf.Dal)
 	dataFileWriteSyncer := zapcore.AddSync(dataFileLoggerWriter)
 	dataFileCore := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(dataFileWriteSyncer), zap.InfoLevel)
-	dalLog = zap.New(dataFileCore)
-
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				Error("panic in log rotating: %v, stack: %s", r, debug.Stack())
-			}
-		}()
-		for {
-			now := time.Now()
-			next := now.Add(time.Hour)
-			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour(), 0, 0, 0, next.Location())
-			duration := next.Sub(now)
-
-			timer := time.NewTimer(duration)
-			<-timer.C
-
-			// 强制切分所有日志文件
-			rotateIfNotEmpty(infoLoggerWriter)
-			rotateIfNotEmpty(errorLoggerWriter)
-			rotateIfNotEmpty(accessLoggerWriter)
-			rotateIfNotEmpty(panicLoggerWriter)
-			rotateIfNotEmpty(dataFileLoggerWriter)
-		}
-	}()
+	if conf.LogMetrics {
+		dataFileCore = withLogMetrics(dataFileCore, "dal")
+	}
+	dalLog = zap.New(dataFileCore, conf.Dal.options()...)
+
+	auditLoggerWriter := newFileWriter(conf.Audit)
+	auditFileWriteSyncer := zapcore.AddSync(auditLoggerWriter)
+	auditFileCore := zapcore.NewCore(encoder, auditFileWriteSyncer, zap.InfoLevel)
+	if conf.LogMetrics {
+		auditFileCore = withLogMetrics(auditFileCore, "audit")
+	}
+	auditLog = zap.New(auditFileCore, conf.Audit.options()...)
+
+	startRotation([]rotationTask{
+		{writer: infoLoggerWriter, schedule: conf.Info.Rotation},
+		{writer: errorLoggerWriter, schedule: conf.Error.Rotation},
+		{writer: accessLoggerWriter, schedule: conf.Access.Rotation},
+		{writer: panicLoggerWriter, schedule: conf.Panic.Rotation},
+		{writer: dataFileLoggerWriter, schedule: conf.Dal.Rotation},
+		{writer: auditLoggerWriter, schedule: conf.Audit.Rotation},
+	})
 }
 
 func Info(args ...interface{}) {
@@ -151,6 +239,44 @@ func GetDalLog() *zap.Logger {
 	return dalLog
 }
 
+func GetAuditLog() *zap.Logger {
+	return auditLog
+}
+
+// Audit 记录安全相关事件（登录、权限变更、数据导出等），与业务日志分开存放
+func Audit(event string, fields ...zap.Field) {
+	auditLog.Info(event, fields...)
+}
+
+// withLogMetrics 统计通道内 Warn 及以上级别的日志条数
+func withLogMetrics(core zapcore.Core, channel string) zapcore.Core {
+	return zapcore.RegisterHooks(core, func(entry zapcore.Entry) error {
+		if entry.Level >= zapcore.WarnLevel {
+			metrics.LogEntryMetric(entry.Level.String(), channel)
+		}
+		return nil
+	})
+}
+
+// inLogLocation 将时间转换到日志时区
+func inLogLocation(t time.Time) time.Time {
+	if logLocation == nil {
+		return t
+	}
+	return t.In(logLocation)
+}
+
+func newFileWriter(c ChannelConfig) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   getAbsPath(c.Filename),
+		MaxSize:    c.MaxSize,
+		MaxAge:     c.MaxAge,
+		MaxBackups: c.MaxBackups,
+		LocalTime:  true,
+		Compress:   false,
+	}
+}
+
 func rotateIfNotEmpty(writer *lumberjack.Logger) {
 	// 检查文件是否存在且不为空
 	if info, err := os.Stat(writer.Filename); err == nil && info.Size() > 0 {
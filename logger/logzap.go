@@ -41,16 +41,23 @@ type Config struct {
 	// skip is a Skipper that indicates which logs should not be written.
 	// Optional.
 	Skipper Skipper
+	// Location of the time field, takes precedence over LoggerConfig.Location.
+	// Ignored when UTC is true.
+	Location *time.Location
+	// LoggedHeaders switches header logging to allowlist mode: only the listed headers are recorded.
+	// Sensitive headers are still filtered. Empty means all headers except the sensitive ones.
+	LoggedHeaders []string
 }
 
 var (
+	// keys are canonical header names, see http.CanonicalHeaderKey
 	sensitiveHeaders = map[string]struct{}{
 		"Authorization":       {},
 		"Cookie":              {},
 		"Set-Cookie":          {},
-		"X-API-Key":           {},
+		"X-Api-Key":           {},
 		"Proxy-Authorization": {},
-		"WWW-Authenticate":    {},
+		"Www-Authenticate":    {},
 	}
 )
 
@@ -72,6 +79,25 @@ func GinzapWithConfig(logger ZapLogger, conf *Config) gin.HandlerFunc {
 	for _, path := range conf.SkipPaths {
 		skipPaths[path] = true
 	}
+	var loggedHeaders map[string]struct{}
+	if len(conf.LoggedHeaders) > 0 {
+		loggedHeaders = make(map[string]struct{}, len(conf.LoggedHeaders))
+		for _, h := range conf.LoggedHeaders {
+			loggedHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+		}
+	}
+
+	shouldTrack := func(c *gin.Context, path string) bool {
+		if _, ok := skipPaths[path]; ok || (conf.Skipper != nil && conf.Skipper(c)) {
+			return false
+		}
+		for _, reg := range conf.SkipPathRegexps {
+			if reg.MatchString(path) {
+				return false
+			}
+		}
+		return true
+	}
 
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -93,29 +119,41 @@ func GinzapWithConfig(logger ZapLogger, conf *Config) gin.HandlerFunc {
 				bodyStr = filterSensitiveDataForJson(bodyStr)
 			}
 		}
-		c.Next()
-		track := true
-
-		if _, ok := skipPaths[path]; ok || (conf.Skipper != nil && conf.Skipper(c)) {
-			track = false
-		}
-
-		if track && len(conf.SkipPathRegexps) > 0 {
-			for _, reg := range conf.SkipPathRegexps {
-				if !reg.MatchString(path) {
-					continue
-				}
-
-				track = false
-				break
+		// SSE and WebSocket responses only return from c.Next() when the connection closes,
+		// so log an entry as soon as the stream starts and a summary afterwards.
+		sw := &streamWriter{ResponseWriter: c.Writer}
+		sw.onStart = func(kind string) {
+			if !shouldTrack(c, path) {
+				return
 			}
+			logger.Info("stream started",
+				zap.String("stream", kind),
+				zap.String("method", c.Request.Method),
+				zap.String("path", path),
+				zap.String("query", query),
+				zap.String("ip", c.ClientIP()),
+				zap.String("user-agent", c.Request.UserAgent()),
+				zap.Int64("latency", time.Since(start).Milliseconds()),
+			)
 		}
+		c.Writer = sw
+		c.Next()
 
-		if track {
+		if shouldTrack(c, path) {
 			end := time.Now()
 			latency := end.Sub(start)
 			if conf.UTC {
 				end = end.UTC()
+			} else if conf.Location != nil {
+				end = end.In(conf.Location)
+			} else {
+				end = inLogLocation(end)
+			}
+
+			// for streams latency is the time to stream start, duration is the lifetime of the stream
+			duration := latency
+			if sw.started {
+				latency = sw.startedAt.Sub(start)
 			}
 
 			fields := []zapcore.Field{
@@ -126,7 +164,17 @@ func GinzapWithConfig(logger ZapLogger, conf *Config) gin.HandlerFunc {
 				zap.String("ip", c.ClientIP()),
 				zap.String("user-agent", c.Request.UserAgent()),
 				zap.Int64("latency", latency.Milliseconds()),
-				zap.Any("headers", filterSensitiveHeaders(c.Request.Header)),
+				zap.Any("headers", filterHeaders(c.Request.Header, loggedHeaders)),
+			}
+			if id := c.GetString(RequestIDKey); id != "" {
+				fields = append(fields, zap.String(RequestIDKey, id))
+			}
+			if sw.started {
+				fields = append(fields,
+					zap.String("stream", sw.kind),
+					zap.Int64("duration", duration.Milliseconds()),
+					zap.Int("bytes", max(sw.Size(), 0)),
+				)
 			}
 			if conf.TimeFormat != "" {
 				fields = append(fields, zap.String("time", end.Format(conf.TimeFormat)))
@@ -188,6 +236,21 @@ func filterSensitiveDataForJson(body string) string {
 	return body
 }
 
+// RecoveryOption configures RecoveryWithZap and CustomRecoveryWithZap
+type RecoveryOption func(*recoveryOptions)
+
+type recoveryOptions struct {
+	alerter PanicAlerter
+}
+
+// WithPanicAlerter notifies alerter with a summary of every recovered panic.
+// Broken connections are not reported.
+func WithPanicAlerter(alerter PanicAlerter) RecoveryOption {
+	return func(o *recoveryOptions) {
+		o.alerter = alerter
+	}
+}
+
 func defaultHandleRecovery(c *gin.Context, err interface{}) {
 	c.AbortWithStatus(http.StatusInternalServerError)
 }
@@ -197,8 +260,8 @@ func defaultHandleRecovery(c *gin.Context, err interface{}) {
 // All errors are logged using zap.Error().
 // stack means whether output the stack info.
 // The stack info is easy to find where the error occurs but the stack info is too large.
-func RecoveryWithZap(logger ZapLogger, stack bool) gin.HandlerFunc {
-	return CustomRecoveryWithZap(logger, stack, defaultHandleRecovery)
+func RecoveryWithZap(logger ZapLogger, stack bool, opts ...RecoveryOption) gin.HandlerFunc {
+	return CustomRecoveryWithZap(logger, stack, defaultHandleRecovery, opts...)
 }
 
 // CustomRecoveryWithZap returns a gin.HandlerFunc (middleware) with a custom recovery handler
@@ -206,7 +269,11 @@ func RecoveryWithZap(logger ZapLogger, stack bool) gin.HandlerFunc {
 // All errors are logged using zap.Error().
 // stack means whether output the stack info.
 // The stack info is easy to find where the error occurs but the stack info is too large.
-func CustomRecoveryWithZap(logger ZapLogger, stack bool, recovery gin.RecoveryFunc) gin.HandlerFunc {
+func CustomRecoveryWithZap(logger ZapLogger, stack bool, recovery gin.RecoveryFunc, opts ...RecoveryOption) gin.HandlerFunc {
+	o := recoveryOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
@@ -248,6 +315,9 @@ func CustomRecoveryWithZap(logger ZapLogger, stack bool, recovery gin.RecoveryFu
 						zap.String("request", string(httpRequest)),
 					)
 				}
+				if o.alerter != nil {
+					o.alerter.Alert(newPanicEvent(c, err))
+				}
 				recovery(c, err)
 			}
 		}()
@@ -255,15 +325,51 @@ func CustomRecoveryWithZap(logger ZapLogger, stack bool, recovery gin.RecoveryFu
 	}
 }
 
+// 按白名单过滤请求头，白名单为空时记录全部非敏感请求头
+func filterHeaders(headers http.Header, allowed map[string]struct{}) map[string][]string {
+	if allowed == nil {
+		return filterSensitiveHeaders(headers)
+	}
+	filtered := make(map[string][]string, len(allowed))
+	for k, v := range headers {
+		if _, ok := allowed[k]; !ok {
+			continue
+		}
+		if IsSensitiveHeader(k) {
+			filtered[k] = []string{FilteredValue}
+		} else {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
 // 过滤敏感请求头
 func filterSensitiveHeaders(headers http.Header) map[string][]string {
 	filtered := make(map[string][]string)
 	for k, v := range headers {
-		if _, ok := sensitiveHeaders[k]; ok {
-			filtered[k] = []string{"[FILTERED]"}
+		if IsSensitiveHeader(k) {
+			filtered[k] = []string{FilteredValue}
 		} else {
 			filtered[k] = v
 		}
 	}
 	return filtered
 }
+
+// FilteredValue 敏感请求头在日志中的替代值
+const FilteredValue = "[FILTERED]"
+
+// AddSensitiveHeaders 追加敏感请求头，作用于 Ginzap 与 httpclient 的 dal 日志，不区分大小写。
+// 需在初始化阶段、记录日志之前调用
+func AddSensitiveHeaders(names ...string) {
+	for _, name := range names {
+		sensitiveHeaders[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+}
+
+// IsSensitiveHeader 请求头是否需要在日志中过滤，不区分大小写
+func IsSensitiveHeader(name string) bool {
+	_, ok := sensitiveHeaders[http.CanonicalHeaderKey(name)]
+	return ok
+}
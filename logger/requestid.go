@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// RequestIDHeader is the header carrying the request id between services
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the gin.Context key of the request id
+	RequestIDKey = "request_id"
+)
+
+type requestIDCtxKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, id)
+}
+
+// RequestIDFromContext returns the request id carried by ctx, ctx may be a *gin.Context
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if id, ok := ctx.Value(requestIDCtxKey{}).(string); ok {
+		return id
+	}
+	if id, ok := ctx.Value(RequestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// RequestIDField returns the request_id field of ctx, or zap.Skip() if there is none
+func RequestIDField(ctx context.Context) zap.Field {
+	id := RequestIDFromContext(ctx)
+	if id == "" {
+		return zap.Skip()
+	}
+	return zap.String(RequestIDKey, id)
+}
+
+// RequestID returns a gin.HandlerFunc (middleware) that reuses the incoming X-Request-ID
+// or generates a new one, and stores it in both the gin.Context and the request context
+// so DalHttpClient and rpc logs of the same request carry it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" {
+			id = NewRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// NewRequestID generates a random request id
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/natefinch/lumberjack"
+	"github.com/robfig/cron/v3"
+)
+
+// RotationSchedule 日志强制切分计划，Next 返回 t 之后的下一次切分时间，返回零值表示不再切分
+type RotationSchedule interface {
+	Next(t time.Time) time.Time
+}
+
+// RotationScheduleFunc 函数形式的切分计划
+type RotationScheduleFunc func(t time.Time) time.Time
+
+func (f RotationScheduleFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+// HourlyRotation 每个整点切分
+func HourlyRotation() RotationSchedule {
+	return RotationScheduleFunc(func(t time.Time) time.Time {
+		next := t.Add(time.Hour)
+		return time.Date(next.Year(), next.Month(), next.Day(), next.Hour(), 0, 0, 0, next.Location())
+	})
+}
+
+// DailyRotation 每天在 hour:minute 切分
+func DailyRotation(hour, minute int) RotationSchedule {
+	return RotationScheduleFunc(func(t time.Time) time.Time {
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	})
+}
+
+// CronRotation 按标准 cron 表达式切分，例如 "0 */6 * * *"
+func CronRotation(spec string) (RotationSchedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+type rotationTask struct {
+	writer   *lumberjack.Logger
+	schedule RotationSchedule
+}
+
+var (
+	rotationMu   sync.Mutex
+	rotationStop chan struct{}
+	rotationWg   sync.WaitGroup
+)
+
+func startRotation(tasks []rotationTask) {
+	rotationMu.Lock()
+	defer rotationMu.Unlock()
+	stop := make(chan struct{})
+	rotationStop = stop
+	for _, task := range tasks {
+		if task.schedule == nil {
+			continue
+		}
+		rotationWg.Add(1)
+		go runRotation(task, stop)
+	}
+}
+
+// StopRotation 停止所有日志切分任务并等待其退出
+func StopRotation() {
+	rotationMu.Lock()
+	defer rotationMu.Unlock()
+	if rotationStop == nil {
+		return
+	}
+	close(rotationStop)
+	rotationWg.Wait()
+	rotationStop = nil
+}
+
+func runRotation(task rotationTask, stop <-chan struct{}) {
+	defer rotationWg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			Error("panic in log rotating: %v, stack: %s", r, debug.Stack())
+		}
+	}()
+	for {
+		now := inLogLocation(time.Now())
+		next := task.schedule.Next(now)
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// 强制切分日志文件
+		rotateIfNotEmpty(task.writer)
+	}
+}
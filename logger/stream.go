@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	streamKindSSE     = "sse"
+	streamKindUpgrade = "upgrade"
+	streamKindStream  = "stream"
+)
+
+// streamWriter detects streaming responses: a Hijack means a protocol upgrade (e.g. WebSocket),
+// a Flush means the handler streams its body (e.g. SSE via c.Stream).
+type streamWriter struct {
+	gin.ResponseWriter
+	onStart func(kind string)
+
+	started   bool
+	kind      string
+	startedAt time.Time
+}
+
+func (w *streamWriter) Flush() {
+	w.ResponseWriter.Flush()
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.start(streamKindSSE)
+	} else {
+		w.start(streamKindStream)
+	}
+}
+
+func (w *streamWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.Hijack()
+	if err == nil {
+		w.start(streamKindUpgrade)
+	}
+	return conn, rw, err
+}
+
+func (w *streamWriter) start(kind string) {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.kind = kind
+	w.startedAt = time.Now()
+	if w.onStart != nil {
+		w.onStart(kind)
+	}
+}
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Cache proxy metrics
+var (
+	cacheHitsTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "hits_total",
+			Help:      "Total number of cache hits",
+		},
+		[]string{"name"},
+	))
+
+	cacheMissesTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "misses_total",
+			Help:      "Total number of cache misses",
+		},
+		[]string{"name"},
+	))
+
+	// type: force / background / scheduled
+	cacheRefreshTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "refresh_total",
+			Help:      "Total number of cache refreshes",
+		},
+		[]string{"name", "type"},
+	))
+
+	cacheBackSourceErrorsTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "back_source_errors_total",
+			Help:      "Total number of failed back-source calls",
+		},
+		[]string{"name"},
+	))
+
+	// result: submitted / dropped / caller_runs
+	cacheAsyncTasksTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "async_tasks_total",
+			Help:      "Total number of cache background tasks",
+		},
+		[]string{"name", "result"},
+	))
+
+	cacheAsyncQueueLength = mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cache",
+			Name:      "async_queue_length",
+			Help:      "Number of cache background tasks waiting in queue",
+		},
+		[]string{"name"},
+	))
+
+	// policy: pass_through / truncate
+	cacheOversizeTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "oversize_total",
+			Help:      "Total number of values exceeding the max cached value size",
+		},
+		[]string{"name", "policy"},
+	))
+
+	// result: flushed / failed
+	cacheWriteBehindTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "write_behind_keys_total",
+			Help:      "Total number of keys written to the source by write-behind",
+		},
+		[]string{"name", "result"},
+	))
+
+	cacheBloomRejectedTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "bloom_rejected_total",
+			Help:      "Total number of back-source lookups skipped by the bloom filter",
+		},
+		[]string{"name"},
+	))
+
+	cacheThrottledTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cache",
+			Name:      "back_source_throttled_total",
+			Help:      "Total number of keys whose back-source was rejected by the rate limiter",
+		},
+		[]string{"name"},
+	))
+
+	cacheBackSourceDuration = mustRegister(prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "cache",
+			Name:      "back_source_duration_milliseconds",
+			Help:      "Back-source getter latency (milliseconds)",
+			Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+		},
+		[]string{"name"},
+	))
+)
+
+const (
+	CacheRefreshForce      = "force"
+	CacheRefreshBackground = "background"
+	CacheRefreshScheduled  = "scheduled"
+
+	CacheAsyncSubmitted  = "submitted"
+	CacheAsyncDropped    = "dropped"
+	CacheAsyncCallerRuns = "caller_runs"
+
+	CacheWriteBehindFlushed = "flushed"
+	CacheWriteBehindFailed  = "failed"
+)
+
+func CacheHitMetric(name string, n int) {
+	cacheHitsTotal.WithLabelValues(name).Add(float64(n))
+}
+
+func CacheMissMetric(name string, n int) {
+	cacheMissesTotal.WithLabelValues(name).Add(float64(n))
+}
+
+func CacheRefreshMetric(name string, refreshType string) {
+	cacheRefreshTotal.WithLabelValues(name, refreshType).Inc()
+}
+
+func CacheBackSourceMetric(name string, elapsed time.Duration, err error) {
+	cacheBackSourceDuration.WithLabelValues(name).Observe(float64(elapsed.Milliseconds()))
+	if err != nil {
+		cacheBackSourceErrorsTotal.WithLabelValues(name).Inc()
+	}
+}
+
+func CacheAsyncTaskMetric(name string, result string) {
+	cacheAsyncTasksTotal.WithLabelValues(name, result).Inc()
+}
+
+func CacheAsyncQueueMetric(name string, n int) {
+	cacheAsyncQueueLength.WithLabelValues(name).Set(float64(n))
+}
+
+func CacheOversizeMetric(name string, policy string) {
+	cacheOversizeTotal.WithLabelValues(name, policy).Inc()
+}
+
+func CacheWriteBehindMetric(name string, result string, n int) {
+	cacheWriteBehindTotal.WithLabelValues(name, result).Add(float64(n))
+}
+
+func CacheBloomRejectedMetric(name string, n int) {
+	cacheBloomRejectedTotal.WithLabelValues(name).Add(float64(n))
+}
+
+func CacheThrottledMetric(name string, n int) {
+	cacheThrottledTotal.WithLabelValues(name).Add(float64(n))
+}
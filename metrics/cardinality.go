@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherEndpoint is the endpoint label of requests beyond MetricsOptions.MaxEndpoints
+const OtherEndpoint = "other"
+
+// endpointGuard limits the number of distinct endpoint label values
+type endpointGuard struct {
+	max     int
+	tracked prometheus.Gauge
+
+	mu   sync.RWMutex
+	seen map[string]struct{}
+}
+
+func newEndpointGuard(maxEndpoints int, tracked prometheus.Gauge) *endpointGuard {
+	return &endpointGuard{max: maxEndpoints, tracked: tracked, seen: make(map[string]struct{})}
+}
+
+// label 返回 endpoint 的标签值，超过上限的新 endpoint 归入 OtherEndpoint
+func (g *endpointGuard) label(endpoint string) string {
+	g.mu.RLock()
+	_, ok := g.seen[endpoint]
+	g.mu.RUnlock()
+	if ok {
+		return endpoint
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.seen[endpoint]; ok {
+		return endpoint
+	}
+	if g.max > 0 && len(g.seen) >= g.max {
+		return OtherEndpoint
+	}
+	g.seen[endpoint] = struct{}{}
+	g.tracked.Set(float64(len(g.seen)))
+	return endpoint
+}
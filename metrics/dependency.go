@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outbound dependency metrics shared by httpclient, cacheproxy and rpc
+var (
+	// result: ok / error
+	dependencyRequestsTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dependency_requests_total",
+			Help: "Total number of calls to outbound dependencies",
+		},
+		[]string{"dependency", "result"},
+	))
+
+	dependencyLatency = mustRegister(prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "dependency_latency_ms",
+			Help:    "Outbound dependency call latency (milliseconds)",
+			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+		},
+		[]string{"dependency"},
+	))
+)
+
+const (
+	DependencyOK    = "ok"
+	DependencyError = "error"
+)
+
+// DependencyMetric records calls to one outbound dependency
+type DependencyMetric struct {
+	ok      prometheus.Counter
+	failed  prometheus.Counter
+	latency prometheus.Observer
+}
+
+var dependencies sync.Map
+
+// Dependency returns the metric of the dependency called name, e.g. a downstream host, "redis" or an RPC subject
+func Dependency(name string) *DependencyMetric {
+	if d, ok := dependencies.Load(name); ok {
+		return d.(*DependencyMetric)
+	}
+	d, _ := dependencies.LoadOrStore(name, &DependencyMetric{
+		ok:      dependencyRequestsTotal.WithLabelValues(name, DependencyOK),
+		failed:  dependencyRequestsTotal.WithLabelValues(name, DependencyError),
+		latency: dependencyLatency.WithLabelValues(name),
+	})
+	return d.(*DependencyMetric)
+}
+
+// ObserveCall records one call, err != nil counts as an error
+func (d *DependencyMetric) ObserveCall(latency time.Duration, err error) {
+	d.latency.Observe(float64(latency.Milliseconds()))
+	if err != nil {
+		d.failed.Inc()
+	} else {
+		d.ok.Inc()
+	}
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Business metrics created through Counter, Gauge and Histogram, keyed by name
+var facade = struct {
+	mu          sync.Mutex
+	namespace   string
+	constLabels prometheus.Labels
+	vecs        map[string]facadeVec
+}{
+	vecs: make(map[string]facadeVec),
+}
+
+type facadeVec struct {
+	// metric 为 *CounterVec、*GaugeVec 或 *HistogramVec
+	metric any
+	labels []string
+}
+
+// CounterVec is a counter created by Counter
+type CounterVec struct {
+	vec *prometheus.CounterVec
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Inc()
+}
+
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Add(v)
+}
+
+// GaugeVec is a gauge created by Gauge
+type GaugeVec struct {
+	vec *prometheus.GaugeVec
+}
+
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Set(v)
+}
+
+func (g *GaugeVec) Add(v float64, labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Add(v)
+}
+
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Inc()
+}
+
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Dec()
+}
+
+// HistogramVec is a histogram created by Histogram
+type HistogramVec struct {
+	vec *prometheus.HistogramVec
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.vec.WithLabelValues(labelValues...).Observe(v)
+}
+
+// Counter returns the counter called name with the given label names, e.g.
+// metrics.Counter("orders_created_total", "channel").Inc("app").
+// The Namespace and ConstLabels of Init are applied, so create business metrics after Init.
+// It panics when name was already created with another type or other label names
+func Counter(name string, labels ...string) *CounterVec {
+	return facadeGet(name, labels, func(namespace string, constLabels prometheus.Labels) *CounterVec {
+		return &CounterVec{vec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: name, Help: name, ConstLabels: constLabels,
+		}, labels)}
+	}, func(c *CounterVec) prometheus.Collector { return c.vec })
+}
+
+// Gauge returns the gauge called name with the given label names, see Counter
+func Gauge(name string, labels ...string) *GaugeVec {
+	return facadeGet(name, labels, func(namespace string, constLabels prometheus.Labels) *GaugeVec {
+		return &GaugeVec{vec: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: name, Help: name, ConstLabels: constLabels,
+		}, labels)}
+	}, func(g *GaugeVec) prometheus.Collector { return g.vec })
+}
+
+// Histogram returns the histogram called name with the given label names, see Counter.
+// buckets defaults to prometheus.DefBuckets and is ignored when name already exists
+func Histogram(name string, buckets []float64, labels ...string) *HistogramVec {
+	return facadeGet(name, labels, func(namespace string, constLabels prometheus.Labels) *HistogramVec {
+		return &HistogramVec{vec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: name, Help: name, ConstLabels: constLabels, Buckets: buckets,
+		}, labels)}
+	}, func(h *HistogramVec) prometheus.Collector { return h.vec })
+}
+
+// facadeGet 返回已创建的指标，不存在时创建并注册
+func facadeGet[T any](name string, labels []string, create func(string, prometheus.Labels) *T, collector func(*T) prometheus.Collector) *T {
+	facade.mu.Lock()
+	defer facade.mu.Unlock()
+	if existing, ok := facade.vecs[name]; ok {
+		v, ok := existing.metric.(*T)
+		if !ok || !slices.Equal(existing.labels, labels) {
+			panic(fmt.Sprintf("metrics: %s already created with another type or labels %v", name, existing.labels))
+		}
+		return v
+	}
+	v := create(facade.namespace, facade.constLabels)
+	mustRegister(collector(v))
+	facade.vecs[name] = facadeVec{metric: v, labels: slices.Clone(labels)}
+	return v
+}
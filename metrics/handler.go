@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// HandlerOptions protects the metrics endpoint, the zero value serves metrics to everyone
+type HandlerOptions struct {
+	// AllowedCIDRs restricts scrapers by remote address, entries are CIDRs like "10.0.0.0/8" or single IPs.
+	// The address is taken from the connection, X-Forwarded-For is not trusted
+	AllowedCIDRs []string
+	// BasicAuthUser and BasicAuthPassword enable basic auth when both are set
+	BasicAuthUser     string
+	BasicAuthPassword string
+	// BearerToken enables "Authorization: Bearer <token>", either credential is accepted when both are set
+	BearerToken string
+}
+
+// Handler serves the metrics of the current registry, create it after UseRegistry.
+// OpenMetrics is negotiated to expose exemplars.
+// Disallowed addresses get 404 like MetricWhitelist, missing or wrong credentials get 401.
+// It panics on an invalid entry in AllowedCIDRs, e.g.
+//
+//	router.GET("/metrics", gin.WrapH(metrics.Handler(metrics.HandlerOptions{AllowedCIDRs: []string{"10.0.0.0/8"}})))
+func Handler(opts HandlerOptions) http.Handler {
+	prefixes, err := parsePrefixes(opts.AllowedCIDRs)
+	if err != nil {
+		panic(err)
+	}
+	next := promhttp.HandlerFor(Gatherer(), promhttp.HandlerOpts{EnableOpenMetrics: true})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !remoteAllowed(prefixes, r.RemoteAddr) {
+			http.NotFound(w, r)
+			return
+		}
+		if !opts.authorized(r) {
+			if opts.BasicAuthUser != "" && opts.BasicAuthPassword != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+			}
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("metrics: invalid allowed address %q: %w", cidr, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("metrics: invalid allowed CIDR %q: %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// remoteAllowed 未配置 CIDR 时允许所有地址
+func remoteAllowed(prefixes []netip.Prefix, remoteAddr string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorized 未配置凭证时不校验
+func (o HandlerOptions) authorized(r *http.Request) bool {
+	basic := o.BasicAuthUser != "" && o.BasicAuthPassword != ""
+	if !basic && o.BearerToken == "" {
+		return true
+	}
+	if basic {
+		if user, password, ok := r.BasicAuth(); ok && secureEqual(user, o.BasicAuthUser) && secureEqual(password, o.BasicAuthPassword) {
+			return true
+		}
+	}
+	if o.BearerToken != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureEqual(token, o.BearerToken) {
+			return true
+		}
+	}
+	return false
+}
+
+func secureEqual(a string, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
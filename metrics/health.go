@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultHealthCheckTimeout = 3 * time.Second
+
+// Health check metrics
+var (
+	healthCheckStatus = mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_check_status",
+			Help: "Result of the latest readiness check (1 healthy, 0 unhealthy)",
+		},
+		[]string{"check"},
+	))
+
+	healthCheckDuration = mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_check_duration_milliseconds",
+			Help: "Latency of the latest readiness check (milliseconds)",
+		},
+		[]string{"check"},
+	))
+)
+
+// HealthCheck returns nil when the component is ready, ctx carries the check timeout
+type HealthCheck func(ctx context.Context) error
+
+type healthCheck struct {
+	timeout time.Duration
+	check   HealthCheck
+}
+
+var healthChecks = struct {
+	mu     sync.RWMutex
+	checks map[string]healthCheck
+}{
+	checks: make(map[string]healthCheck),
+}
+
+// RegisterHealthCheck adds a readiness check, timeout <= 0 defaults to 3s.
+// Registering the same name again replaces the check
+func RegisterHealthCheck(name string, timeout time.Duration, check HealthCheck) {
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	healthChecks.mu.Lock()
+	defer healthChecks.mu.Unlock()
+	healthChecks.checks[name] = healthCheck{timeout: timeout, check: check}
+}
+
+// HealthHandler serves /livez, which reports the process is up, and /readyz, which runs all
+// registered checks concurrently and returns 503 when any fails. Both respond with JSON, e.g.
+//
+//	router.Any("/health/*path", gin.WrapH(metrics.HealthHandler()))
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/livez"):
+			writeHealth(w, http.StatusOK, healthReport{Status: healthOK})
+		case strings.HasSuffix(r.URL.Path, "/readyz"):
+			report := runHealthChecks(r.Context())
+			status := http.StatusOK
+			if report.Status != healthOK {
+				status = http.StatusServiceUnavailable
+			}
+			writeHealth(w, status, report)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+const (
+	healthOK   = "ok"
+	healthFail = "fail"
+)
+
+type healthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]healthCheckResult `json:"checks,omitempty"`
+}
+
+type healthCheckResult struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// runHealthChecks 并发执行所有检查并更新监控
+func runHealthChecks(ctx context.Context) healthReport {
+	healthChecks.mu.RLock()
+	checks := make(map[string]healthCheck, len(healthChecks.checks))
+	for name, c := range healthChecks.checks {
+		checks[name] = c
+	}
+	healthChecks.mu.RUnlock()
+
+	report := healthReport{Status: healthOK, Checks: make(map[string]healthCheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.run(ctx)
+			value := 1.0
+			if result.Status != healthOK {
+				value = 0
+			}
+			healthCheckStatus.WithLabelValues(name).Set(value)
+			healthCheckDuration.WithLabelValues(name).Set(float64(result.LatencyMs))
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != healthOK {
+				report.Status = healthFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// run 检查超时或 panic 时视为失败
+func (c healthCheck) run(ctx context.Context) (result healthCheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("health check panic: %v", r)
+			}
+		}()
+		done <- c.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result = healthCheckResult{Status: healthOK, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = healthFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func writeHealth(w http.ResponseWriter, status int, report healthReport) {
+	body, err := sonic.Marshal(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// RedisHealthCheck pings rdb
+func RedisHealthCheck(rdb redis.UniversalClient) HealthCheck {
+	return func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}
+}
+
+// NatsHealthCheck reports whether nc is connected
+func NatsHealthCheck(nc *nats.Conn) HealthCheck {
+	return func(ctx context.Context) error {
+		if status := nc.Status(); status != nats.CONNECTED {
+			return fmt.Errorf("nats connection %s", status)
+		}
+		return nil
+	}
+}
+
+// Pinger is implemented by *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DBHealthCheck pings a database, e.g. *sql.DB
+func DBHealthCheck(db Pinger) HealthCheck {
+	return db.PingContext
+}
+
+// HTTPHealthCheck sends a GET to url and expects a 2xx response
+func HTTPHealthCheck(url string) HealthCheck {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return errors.New("unexpected status " + resp.Status)
+		}
+		return nil
+	}
+}
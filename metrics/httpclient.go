@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outbound HTTP client metrics
+var (
+	// 0: closed, 1: open, 2: half-open
+	httpClientBreakerState = mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "httpclient",
+			Name:      "breaker_state",
+			Help:      "Circuit breaker state per downstream host (0 closed, 1 open, 2 half-open)",
+		},
+		[]string{"host"},
+	))
+
+	httpClientBreakerTransitionsTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "httpclient",
+			Name:      "breaker_transitions_total",
+			Help:      "Total number of circuit breaker state changes per downstream host",
+		},
+		[]string{"host", "state"},
+	))
+)
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+var breakerStateValues = map[string]float64{
+	BreakerClosed:   0,
+	BreakerOpen:     1,
+	BreakerHalfOpen: 2,
+}
+
+func HttpClientBreakerMetric(host string, state string) {
+	httpClientBreakerState.WithLabelValues(host).Set(breakerStateValues[state])
+	httpClientBreakerTransitionsTotal.WithLabelValues(host, state).Inc()
+}
+
+// Webhook delivery metrics
+var (
+	// result: delivered / retry / failed
+	webhookDeliveriesTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "webhook",
+			Name:      "deliveries_total",
+			Help:      "Total number of webhook delivery attempts per destination host",
+		},
+		[]string{"destination", "result"},
+	))
+
+	webhookDeliveryDuration = mustRegister(prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "webhook",
+			Name:      "delivery_duration_milliseconds",
+			Help:      "Webhook delivery attempt latency (milliseconds)",
+			Buckets:   []float64{10, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+		},
+		[]string{"destination"},
+	))
+)
+
+const (
+	WebhookDelivered = "delivered"
+	WebhookRetry     = "retry"
+	WebhookFailed    = "failed"
+)
+
+func WebhookDeliveryMetric(destination string, result string, elapsed time.Duration) {
+	webhookDeliveriesTotal.WithLabelValues(destination, result).Inc()
+	webhookDeliveryDuration.WithLabelValues(destination).Observe(float64(elapsed.Milliseconds()))
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go/micro"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NATS RPC handler metrics
+var (
+	// result: ok / error
+	natsRPCRequestsTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "nats_rpc",
+			Name:      "requests_total",
+			Help:      "Total number of NATS RPC requests",
+		},
+		[]string{"subject", "result"},
+	))
+
+	natsRPCErrorsTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "nats_rpc",
+			Name:      "errors_total",
+			Help:      "Total number of NATS RPC error responses by error code",
+		},
+		[]string{"subject", "code"},
+	))
+
+	natsRPCRequestDuration = mustRegister(prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "nats_rpc",
+			Name:      "request_duration_milliseconds",
+			Help:      "NATS RPC request processing time (milliseconds)",
+			Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 800, 1000, 2000, 5000},
+		},
+		[]string{"subject"},
+	))
+)
+
+const (
+	NatsRPCResultOK    = "ok"
+	NatsRPCResultError = "error"
+
+	// NatsRPCPanicCode is the error code recorded when the handler panics
+	NatsRPCPanicCode = "panic"
+)
+
+// metricsRequest records the error code passed to Error
+type metricsRequest struct {
+	micro.Request
+	errCode string
+	failed  bool
+}
+
+func (r *metricsRequest) Error(code, description string, data []byte, opts ...micro.RespondOpt) error {
+	r.failed = true
+	r.errCode = code
+	return r.Request.Error(code, description, data, opts...)
+}
+
+// NatsRPCMiddleware records request count, error count and latency per subject.
+// A request is counted as an error when the handler calls Error or panics, e.g.
+//
+//	micro.ContextHandler(ctx, rpc.Chain(handler, rpc.NatsRpcAccessLog, metrics.NatsRPCMiddleware))
+func NatsRPCMiddleware(fn func(context.Context, micro.Request)) func(context.Context, micro.Request) {
+	return func(ctx context.Context, rawReq micro.Request) {
+		req := &metricsRequest{Request: rawReq}
+		subject := rawReq.Subject()
+		start := time.Now()
+		defer func() {
+			r := recover()
+			if r != nil {
+				req.failed = true
+				req.errCode = NatsRPCPanicCode
+			}
+			observeWithTrace(ctx, natsRPCRequestDuration.WithLabelValues(subject), float64(time.Since(start).Milliseconds()))
+			if req.failed {
+				natsRPCRequestsTotal.WithLabelValues(subject, NatsRPCResultError).Inc()
+				natsRPCErrorsTotal.WithLabelValues(subject, req.errCode).Inc()
+			} else {
+				natsRPCRequestsTotal.WithLabelValues(subject, NatsRPCResultOK).Inc()
+			}
+			// 交给外层的 NatsRpcAccessLog 恢复并记录
+			if r != nil {
+				panic(r)
+			}
+		}()
+		fn(ctx, req)
+	}
+}
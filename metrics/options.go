@@ -0,0 +1,364 @@
+package metrics
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	defaultNativeHistogramBucketFactor = 1.1
+	// 限制 native histogram 的桶数，超过时按 NativeHistogramMinResetDuration 重置或降低精度
+	nativeHistogramMaxBuckets       = 160
+	nativeHistogramMinResetDuration = time.Hour
+)
+
+var (
+	defaultLatencyBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 800, 1000, 2000, 5000}
+	defaultSizeBuckets    = []float64{1024, 10 * 1024, 100 * 1024, 512 * 1024, 1024 * 1024, 5 * 1024 * 1024, 10 * 1024 * 1024}
+)
+
+// MetricNames overrides the names of the HTTP server metrics, empty fields keep the defaults
+type MetricNames struct {
+	RequestsTotal    string
+	RequestDuration  string
+	RequestSize      string
+	ResponseSize     string
+	RequestsInFlight string
+	ResponseTotal    string
+	ApdexTotal       string
+	TrackedEndpoints string
+	RequestsByClass  string
+	InFlightByMethod string
+	QueueTime        string
+}
+
+// ApdexConfig classifies requests into satisfied (latency <= T), tolerating (<= 4T)
+// and frustrated (> 4T or 5xx) zones, the apdex score is (satisfied + tolerating/2) / total
+type ApdexConfig struct {
+	// Target is the default T, apdex is disabled when both Target and Routes are empty
+	Target time.Duration
+	// Routes overrides T per endpoint, keyed like the endpoint label, e.g. "GET_/api/users/:id"
+	Routes map[string]time.Duration
+}
+
+// target returns T for endpoint, false when the endpoint is not measured
+func (c ApdexConfig) target(endpoint string) (time.Duration, bool) {
+	if t, ok := c.Routes[endpoint]; ok {
+		return t, t > 0
+	}
+	return c.Target, c.Target > 0
+}
+
+// MetricsOptions configures the HTTP server metrics recorded by PrometheusGinMiddleware
+type MetricsOptions struct {
+	Namespace string
+	// Subsystem defaults to "http"
+	Subsystem string
+	// ConstLabels are attached to every HTTP metric and business metric, e.g. app and env
+	ConstLabels prometheus.Labels
+	// LatencyBuckets in milliseconds
+	LatencyBuckets []float64
+	// SizeBuckets in bytes, used by both request and response size
+	SizeBuckets []float64
+	Names       MetricNames
+	Apdex       ApdexConfig
+	// MaxEndpoints limits distinct endpoint label values, requests to further endpoints are
+	// recorded as OtherEndpoint. 0 means no limit
+	MaxEndpoints int
+	// MeterProvider switches the middleware to record into OpenTelemetry instruments of this provider
+	// instead of Prometheus collectors, see NewOTLPMeterProvider
+	MeterProvider metric.MeterProvider
+	// StatsD additionally sends the HTTP server metrics to a StatsD agent when set
+	StatsD *StatsDConfig
+	// ExtraLabels declares the label names LabelFn may set, e.g. tenant or client_app
+	ExtraLabels []string
+	// NativeHistograms additionally records the latency and size histograms as Prometheus native
+	// histograms. Prometheus >= 2.40 with the native histograms feature scrapes the sparse buckets,
+	// other scrapers keep using the classic buckets
+	NativeHistograms bool
+	// NativeHistogramBucketFactor is the growth factor between native buckets, defaults to 1.1
+	NativeHistogramBucketFactor float64
+	// LabelFn returns values of ExtraLabels for a request before it is handled,
+	// undeclared names are ignored and missing ones are recorded as ""
+	LabelFn func(c *gin.Context) prometheus.Labels
+}
+
+func (o MetricsOptions) withDefaults() MetricsOptions {
+	if o.Subsystem == "" {
+		o.Subsystem = "http"
+	}
+	if len(o.LatencyBuckets) == 0 {
+		o.LatencyBuckets = defaultLatencyBuckets
+	}
+	if len(o.SizeBuckets) == 0 {
+		o.SizeBuckets = defaultSizeBuckets
+	}
+	if !o.NativeHistograms {
+		o.NativeHistogramBucketFactor = 0
+	} else if o.NativeHistogramBucketFactor <= 1 {
+		o.NativeHistogramBucketFactor = defaultNativeHistogramBucketFactor
+	}
+	o.Names.RequestsTotal = orDefault(o.Names.RequestsTotal, "http_requests_total")
+	o.Names.RequestDuration = orDefault(o.Names.RequestDuration, "http_request_duration_milliseconds")
+	o.Names.RequestSize = orDefault(o.Names.RequestSize, "http_request_size_bytes")
+	o.Names.ResponseSize = orDefault(o.Names.ResponseSize, "http_response_size_bytes")
+	o.Names.RequestsInFlight = orDefault(o.Names.RequestsInFlight, "http_requests_in_flight")
+	o.Names.ResponseTotal = orDefault(o.Names.ResponseTotal, "total")
+	o.Names.ApdexTotal = orDefault(o.Names.ApdexTotal, "apdex_total")
+	o.Names.TrackedEndpoints = orDefault(o.Names.TrackedEndpoints, "tracked_endpoints")
+	o.Names.RequestsByClass = orDefault(o.Names.RequestsByClass, "http_requests_by_class_total")
+	o.Names.InFlightByMethod = orDefault(o.Names.InFlightByMethod, "http_requests_in_flight_by_method")
+	o.Names.QueueTime = orDefault(o.Names.QueueTime, "http_request_queue_time_milliseconds")
+	return o
+}
+
+// nativeMaxBuckets 未启用 native histogram 时为 0
+func (o MetricsOptions) nativeMaxBuckets() uint32 {
+	if !o.NativeHistograms {
+		return 0
+	}
+	return nativeHistogramMaxBuckets
+}
+
+func (o MetricsOptions) nativeMinResetDuration() time.Duration {
+	if !o.NativeHistograms {
+		return 0
+	}
+	return nativeHistogramMinResetDuration
+}
+
+func orDefault(name string, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
+
+// httpMetricsInit 是否已创建 HTTP 指标，未调用 Init 时在首次使用时按默认配置创建
+var (
+	httpMetricsMu   sync.Mutex
+	httpMetricsInit atomic.Bool
+)
+
+// ensureHTTPMetrics 未调用 Init 时按默认配置注册 HTTP 指标
+func ensureHTTPMetrics() {
+	if httpMetricsInit.Load() {
+		return
+	}
+	httpMetricsMu.Lock()
+	defer httpMetricsMu.Unlock()
+	if !httpMetricsInit.Load() {
+		if err := registerHTTPMetrics(MetricsOptions{}); err != nil {
+			panic(err)
+		}
+		httpMetricsInit.Store(true)
+	}
+}
+
+// Init replaces the HTTP server metrics with collectors built from opts.
+// It must be called before PrometheusGinMiddleware is installed, series recorded earlier are dropped.
+// A registry does not accept the same metric name with different label names, so changing
+// ExtraLabels after the metrics were registered returns an error
+func Init(opts MetricsOptions) error {
+	httpMetricsMu.Lock()
+	defer httpMetricsMu.Unlock()
+	if httpMetricsInit.Load() {
+		for _, c := range []prometheus.Collector{
+			httpRequestsTotal, httpRequestDuration, httpRequestSize,
+			httpResponseSize, httpRequestsInFlight, responseCounterTotal, httpApdexTotal, httpTrackedEndpoints,
+			httpRequestsByClass, httpRequestsInFlightByMethod, httpRequestQueueTime,
+		} {
+			unregister(c)
+		}
+	}
+	if err := registerHTTPMetrics(opts); err != nil {
+		return err
+	}
+	httpMetricsInit.Store(true)
+	facade.mu.Lock()
+	facade.namespace = opts.Namespace
+	facade.constLabels = opts.ConstLabels
+	facade.mu.Unlock()
+	var rec httpRecorder = promRecorder{}
+	if opts.MeterProvider != nil {
+		otelRec, err := newOTelRecorder(opts.MeterProvider, opts.withDefaults())
+		if err != nil {
+			return err
+		}
+		rec = otelRec
+	}
+	if opts.StatsD != nil {
+		statsdRec, err := newStatsDRecorder(*opts.StatsD, opts.ExtraLabels)
+		if err != nil {
+			return err
+		}
+		rec = multiRecorder{rec, statsdRec}
+	}
+	closeRecorder(recorder)
+	recorder = rec
+	return nil
+}
+
+// closeRecorder 关闭被替换的 StatsD 连接
+func closeRecorder(rec httpRecorder) {
+	if m, ok := rec.(multiRecorder); ok {
+		for _, r := range m {
+			closeRecorder(r)
+		}
+	}
+	if c, ok := rec.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
+func registerHTTPMetrics(opts MetricsOptions) error {
+	opts = opts.withDefaults()
+	httpRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.RequestsTotal,
+			Help:        "Total number of HTTP requests",
+			ConstLabels: opts.ConstLabels,
+		},
+		append([]string{"endpoint", "status"}, opts.ExtraLabels...),
+	)
+	httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.RequestDuration,
+			Help:        "HTTP request processing time (milliseconds)",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.LatencyBuckets,
+
+			NativeHistogramBucketFactor:     opts.NativeHistogramBucketFactor,
+			NativeHistogramMaxBucketNumber:  opts.nativeMaxBuckets(),
+			NativeHistogramMinResetDuration: opts.nativeMinResetDuration(),
+		},
+		append([]string{"endpoint"}, opts.ExtraLabels...),
+	)
+	httpRequestSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.RequestSize,
+			Help:        "HTTP request size (bytes)",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.SizeBuckets,
+
+			NativeHistogramBucketFactor:     opts.NativeHistogramBucketFactor,
+			NativeHistogramMaxBucketNumber:  opts.nativeMaxBuckets(),
+			NativeHistogramMinResetDuration: opts.nativeMinResetDuration(),
+		},
+		append([]string{"endpoint"}, opts.ExtraLabels...),
+	)
+	httpResponseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.ResponseSize,
+			Help:        "HTTP response size (bytes)",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.SizeBuckets,
+
+			NativeHistogramBucketFactor:     opts.NativeHistogramBucketFactor,
+			NativeHistogramMaxBucketNumber:  opts.nativeMaxBuckets(),
+			NativeHistogramMinResetDuration: opts.nativeMinResetDuration(),
+		},
+		append([]string{"endpoint"}, opts.ExtraLabels...),
+	)
+	httpRequestsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.RequestsInFlight,
+			Help:        "Number of HTTP requests currently being processed",
+			ConstLabels: opts.ConstLabels,
+		},
+		append([]string{"endpoint"}, opts.ExtraLabels...),
+	)
+	responseCounterTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   "response",
+			Name:        opts.Names.ResponseTotal,
+			Help:        "Total result of response",
+			ConstLabels: opts.ConstLabels,
+		},
+		append([]string{"endpoint", "code"}, opts.ExtraLabels...),
+	)
+	httpApdexTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.ApdexTotal,
+			Help:        "Total number of HTTP requests per apdex zone",
+			ConstLabels: opts.ConstLabels,
+		},
+		append([]string{"endpoint", "zone"}, opts.ExtraLabels...),
+	)
+	httpRequestsByClass = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.RequestsByClass,
+			Help:        "Total number of HTTP requests by status class",
+			ConstLabels: opts.ConstLabels,
+		},
+		append([]string{"endpoint", "class"}, opts.ExtraLabels...),
+	)
+	httpRequestsInFlightByMethod = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.InFlightByMethod,
+			Help:        "Number of HTTP requests currently being processed by method",
+			ConstLabels: opts.ConstLabels,
+		},
+		[]string{"method"},
+	)
+	httpRequestQueueTime = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.QueueTime,
+			Help:        "Time between the load balancer receiving the request and the handler starting it (milliseconds)",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.LatencyBuckets,
+
+			NativeHistogramBucketFactor:     opts.NativeHistogramBucketFactor,
+			NativeHistogramMaxBucketNumber:  opts.nativeMaxBuckets(),
+			NativeHistogramMinResetDuration: opts.nativeMinResetDuration(),
+		},
+	)
+	httpTrackedEndpoints = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        opts.Names.TrackedEndpoints,
+			Help:        "Number of distinct endpoint label values, bounded by MaxEndpoints",
+			ConstLabels: opts.ConstLabels,
+		},
+	)
+	apdexConf = opts.Apdex
+	extraLabels = opts.ExtraLabels
+	labelFn = opts.LabelFn
+	endpoints = newEndpointGuard(opts.MaxEndpoints, httpTrackedEndpoints)
+	for _, c := range []prometheus.Collector{
+		httpRequestsTotal, httpRequestDuration, httpRequestSize,
+		httpResponseSize, httpRequestsInFlight, responseCounterTotal, httpApdexTotal, httpTrackedEndpoints,
+		httpRequestsByClass, httpRequestsInFlightByMethod, httpRequestQueueTime,
+	} {
+		if err := register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
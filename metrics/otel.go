@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// OTLPConfig configures the OTLP/HTTP exporter of NewOTLPMeterProvider
+type OTLPConfig struct {
+	// Endpoint is the collector host and port, e.g. "otel-collector:4318"
+	Endpoint string
+	// URLPath defaults to "/v1/metrics"
+	URLPath  string
+	Insecure bool
+	Headers  map[string]string
+	// Interval between exports, defaults to 60s
+	Interval    time.Duration
+	ServiceName string
+}
+
+// NewOTLPMeterProvider creates a meter provider exporting to an OTel collector over OTLP/HTTP,
+// pass it as MetricsOptions.MeterProvider and call Shutdown on exit to flush the last export
+func NewOTLPMeterProvider(ctx context.Context, conf OTLPConfig) (*sdkmetric.MeterProvider, error) {
+	opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(conf.Endpoint)}
+	if conf.URLPath != "" {
+		opts = append(opts, otlpmetrichttp.WithURLPath(conf.URLPath))
+	}
+	if conf.Insecure {
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	}
+	if len(conf.Headers) > 0 {
+		opts = append(opts, otlpmetrichttp.WithHeaders(conf.Headers))
+	}
+	exporter, err := otlpmetrichttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var readerOpts []sdkmetric.PeriodicReaderOption
+	if conf.Interval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(conf.Interval))
+	}
+	providerOpts := []sdkmetric.Option{sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...))}
+	if conf.ServiceName != "" {
+		providerOpts = append(providerOpts, sdkmetric.WithResource(resource.NewSchemaless(attribute.String("service.name", conf.ServiceName))))
+	}
+	return sdkmetric.NewMeterProvider(providerOpts...), nil
+}
+
+// otelRecorder records the HTTP server metrics into OpenTelemetry instruments
+type otelRecorder struct {
+	attrs       []attribute.KeyValue
+	extraLabels []string
+
+	requests     metric.Int64Counter
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+	active       metric.Int64UpDownCounter
+	queueTime    metric.Float64Histogram
+	apdex        metric.Int64Counter
+	responses    metric.Int64Counter
+}
+
+// newOTelRecorder 指标名为 Prometheus 指标名以 "." 连接，例如 http.http_requests_total
+func newOTelRecorder(provider metric.MeterProvider, opts MetricsOptions) (*otelRecorder, error) {
+	meter := provider.Meter("github.com/TomWu-Alchemi/project-framework/metrics")
+	name := func(subsystem string, metricName string) string {
+		if opts.Namespace != "" {
+			return opts.Namespace + "." + subsystem + "." + metricName
+		}
+		return subsystem + "." + metricName
+	}
+	r := &otelRecorder{extraLabels: opts.ExtraLabels}
+	for k, v := range opts.ConstLabels {
+		r.attrs = append(r.attrs, attribute.String(k, v))
+	}
+	var err error
+	if r.requests, err = meter.Int64Counter(name(opts.Subsystem, opts.Names.RequestsTotal),
+		metric.WithDescription("Total number of HTTP requests")); err != nil {
+		return nil, err
+	}
+	if r.duration, err = meter.Float64Histogram(name(opts.Subsystem, opts.Names.RequestDuration),
+		metric.WithDescription("HTTP request processing time (milliseconds)"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(opts.LatencyBuckets...)); err != nil {
+		return nil, err
+	}
+	if r.requestSize, err = meter.Int64Histogram(name(opts.Subsystem, opts.Names.RequestSize),
+		metric.WithDescription("HTTP request size (bytes)"),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(opts.SizeBuckets...)); err != nil {
+		return nil, err
+	}
+	if r.responseSize, err = meter.Int64Histogram(name(opts.Subsystem, opts.Names.ResponseSize),
+		metric.WithDescription("HTTP response size (bytes)"),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(opts.SizeBuckets...)); err != nil {
+		return nil, err
+	}
+	if r.active, err = meter.Int64UpDownCounter(name(opts.Subsystem, opts.Names.RequestsInFlight),
+		metric.WithDescription("Number of HTTP requests currently being processed")); err != nil {
+		return nil, err
+	}
+	if r.queueTime, err = meter.Float64Histogram(name(opts.Subsystem, opts.Names.QueueTime),
+		metric.WithDescription("Time between the load balancer receiving the request and the handler starting it (milliseconds)"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(opts.LatencyBuckets...)); err != nil {
+		return nil, err
+	}
+	if r.apdex, err = meter.Int64Counter(name(opts.Subsystem, opts.Names.ApdexTotal),
+		metric.WithDescription("Total number of HTTP requests per apdex zone")); err != nil {
+		return nil, err
+	}
+	if r.responses, err = meter.Int64Counter(name("response", opts.Names.ResponseTotal),
+		metric.WithDescription("Total result of response")); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *otelRecorder) with(extra []string, kv ...attribute.KeyValue) metric.MeasurementOption {
+	for i, v := range extra {
+		kv = append(kv, attribute.String(r.extraLabels[i], v))
+	}
+	return metric.WithAttributes(append(kv, r.attrs...)...)
+}
+
+func (r *otelRecorder) inFlight(ctx context.Context, _ string, endpoint string, extra []string, delta int64) {
+	r.active.Add(ctx, delta, r.with(extra, attribute.String("endpoint", endpoint)))
+}
+
+func (r *otelRecorder) record(ctx context.Context, obs httpObservation) {
+	endpoint := attribute.String("endpoint", obs.endpoint)
+	r.requests.Add(ctx, 1, r.with(obs.extra, endpoint, attribute.Int("status", obs.status), attribute.String("class", statusClass(obs.status))))
+	r.duration.Record(ctx, float64(obs.elapsed.Milliseconds()), r.with(obs.extra, endpoint))
+	r.requestSize.Record(ctx, obs.requestSize, r.with(obs.extra, endpoint))
+	r.responseSize.Record(ctx, int64(obs.responseSize), r.with(obs.extra, endpoint))
+	if obs.hasQueueTime {
+		r.queueTime.Record(ctx, float64(obs.queueTime.Milliseconds()), r.with(nil))
+	}
+	if obs.apdexZone != "" {
+		r.apdex.Add(ctx, 1, r.with(obs.extra, endpoint, attribute.String("zone", obs.apdexZone)))
+	}
+	if obs.responseCode != "" {
+		r.responses.Add(ctx, 1, r.with(obs.extra, endpoint, attribute.String("code", obs.responseCode)))
+	}
+}
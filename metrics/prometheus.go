@@ -3,130 +3,158 @@ package metrics
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"net/http"
 	"slices"
 	"strconv"
 	"time"
 )
 
-// Define Prometheus metrics
+// Define Prometheus metrics, HTTP collectors are created by Init
 var (
 	// Request counter
-	httpRequestsTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Subsystem: "http",
-			Name:      "http_requests_total",
-			Help:      "Total number of HTTP requests",
-		},
-		[]string{"endpoint", "status"},
-	)
+	httpRequestsTotal *prometheus.CounterVec
 
 	// Request latency histogram
-	httpRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Subsystem: "http",
-			Name:      "http_request_duration_milliseconds",
-			Help:      "HTTP request processing time (milliseconds)",
-			Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 800, 1000, 2000, 5000},
-		},
-		[]string{"endpoint"},
-	)
+	httpRequestDuration *prometheus.HistogramVec
 
 	// Request size histogram
-	httpRequestSize = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Subsystem: "http",
-			Name:      "http_request_size_bytes",
-			Help:      "HTTP request size (bytes)",
-			Buckets:   []float64{1024, 10 * 1024, 100 * 1024, 512 * 1024, 1024 * 1024, 5 * 1024 * 1024, 10 * 1024 * 1024},
-		},
-		[]string{"endpoint"},
-	)
+	httpRequestSize *prometheus.HistogramVec
 
 	// Response size histogram
-	httpResponseSize = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Subsystem: "http",
-			Name:      "http_response_size_bytes",
-			Help:      "HTTP response size (bytes)",
-			Buckets:   []float64{1024, 10 * 1024, 100 * 1024, 512 * 1024, 1024 * 1024, 5 * 1024 * 1024, 10 * 1024 * 1024},
-		},
-		[]string{"endpoint"},
-	)
+	httpResponseSize *prometheus.HistogramVec
 
 	// Current active requests
-	httpRequestsInFlight = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Subsystem: "http",
-			Name:      "http_requests_in_flight",
-			Help:      "Number of HTTP requests currently being processed",
-		},
-		[]string{"endpoint"},
-	)
+	httpRequestsInFlight *prometheus.GaugeVec
+
+	responseCounterTotal *prometheus.CounterVec
+
+	// Requests per apdex zone, only recorded when ApdexConfig is set
+	httpApdexTotal *prometheus.CounterVec
+	apdexConf      ApdexConfig
 
-	responseCounterTotal = promauto.NewCounterVec(
+	// Request counter by status class (2xx, 4xx, 5xx)
+	httpRequestsByClass *prometheus.CounterVec
+
+	// Current active requests by method
+	httpRequestsInFlightByMethod *prometheus.GaugeVec
+
+	// Time spent queued in the load balancer, from X-Request-Start or X-Queue-Start
+	httpRequestQueueTime prometheus.Histogram
+
+	// Distinct endpoint label values
+	httpTrackedEndpoints prometheus.Gauge
+	endpoints            *endpointGuard
+
+	// Extra label names appended to the HTTP metrics and the func providing their values
+	extraLabels []string
+	labelFn     func(c *gin.Context) prometheus.Labels
+)
+
+var (
+	// Emitted log entries
+	logEntriesTotal = mustRegister(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Subsystem: "response",
-			Name:      "total",
-			Help:      "Total result of response",
+			Subsystem: "log",
+			Name:      "entries_total",
+			Help:      "Total number of emitted log entries",
 		},
-		[]string{"endpoint", "code"},
-	)
+		[]string{"level", "channel"},
+	))
 )
 
 const (
 	ResponseCodeMetricKey = "metric_responseCode"
+
+	ApdexSatisfied  = "satisfied"
+	ApdexTolerating = "tolerating"
+	ApdexFrustrated = "frustrated"
 )
 
 // PrometheusGinMiddleware returns a Gin middleware for collecting Prometheus metrics on HTTP requests
 func PrometheusGinMiddleware() gin.HandlerFunc {
+	ensureHTTPMetrics()
 	return func(c *gin.Context) {
 		path := c.FullPath()
 		if path == "" {
 			path = "unknown"
 		}
 
-		method := c.Request.Method
+		method := normalizeMethod(c.Request.Method)
 		contentLength := c.Request.ContentLength
 
-		// 将方法和路径通过下划线连接
+		// 将方法和路径通过下划线连接，label 为限制基数后的标签值
 		endpoint := method + "_" + path
+		label := endpoints.label(endpoint)
 
-		// 记录请求大小
-		httpRequestSize.WithLabelValues(endpoint).Observe(float64(contentLength))
+		rec := recorder
+		ctx := c.Request.Context()
+		extra := extraLabelValues(c)
 
 		// 增加当前处理的请求数
-		httpRequestsInFlight.WithLabelValues(endpoint).Inc()
-		defer httpRequestsInFlight.WithLabelValues(endpoint).Dec()
+		rec.inFlight(ctx, method, label, extra, 1)
+		defer rec.inFlight(ctx, method, label, extra, -1)
 
 		// 记录开始时间
 		startTime := time.Now()
+		queueTime, hasQueueTime := requestQueueTime(c.Request.Header, startTime)
 
 		// 处理请求
 		c.Next()
 
-		// 计算请求处理时间（毫秒）
-		elapsedTime := float64(time.Since(startTime).Milliseconds())
+		// 在 c.Next 之后取 ctx 以便取到后续中间件创建的 span
+		obs := httpObservation{
+			endpoint:     label,
+			status:       c.Writer.Status(),
+			elapsed:      time.Since(startTime),
+			requestSize:  contentLength,
+			responseSize: c.Writer.Size(),
+			extra:        extra,
+			queueTime:    queueTime,
+			hasQueueTime: hasQueueTime,
+		}
 
-		// 获取响应状态码
-		status := strconv.Itoa(c.Writer.Status())
+		// 计算 apdex 区间
+		if target, ok := apdexConf.target(endpoint); ok {
+			obs.apdexZone = apdexZone(obs.elapsed, target, obs.status)
+		}
 
-		// 记录请求计数
-		httpRequestsTotal.WithLabelValues(endpoint, status).Inc()
+		// 获取业务响应码
+		if responseCode, exist := c.Get(ResponseCodeMetricKey); exist {
+			obs.responseCode = strconv.Itoa(responseCode.(int))
+		}
 
-		// 记录请求处理时间
-		httpRequestDuration.WithLabelValues(endpoint).Observe(elapsedTime)
+		rec.record(c.Request.Context(), obs)
 
-		// 记录响应大小
-		httpResponseSize.WithLabelValues(endpoint).Observe(float64(c.Writer.Size()))
+		// 记录 SLO
+		recordEndpointSLOs(endpoint, obs.status, obs.elapsed)
+	}
+}
 
-		// 记录业务响应情况
-		responseCode, exist := c.Get(ResponseCodeMetricKey)
-		if exist {
-			code := responseCode.(int)
-			responseCounterTotal.WithLabelValues(endpoint, strconv.Itoa(code)).Inc()
-		}
+// extraLabelValues 按 ExtraLabels 的顺序返回 LabelFn 设置的标签值
+func extraLabelValues(c *gin.Context) []string {
+	if len(extraLabels) == 0 {
+		return nil
+	}
+	values := make([]string, len(extraLabels))
+	if labelFn == nil {
+		return values
+	}
+	labels := labelFn(c)
+	for i, name := range extraLabels {
+		values[i] = labels[name]
+	}
+	return values
+}
+
+// apdexZone 5xx 响应计为 frustrated
+func apdexZone(elapsed time.Duration, target time.Duration, status int) string {
+	switch {
+	case status >= http.StatusInternalServerError || elapsed > 4*target:
+		return ApdexFrustrated
+	case elapsed > target:
+		return ApdexTolerating
+	default:
+		return ApdexSatisfied
 	}
 }
 
@@ -146,5 +174,10 @@ func MetricWhitelist(ipList []string) gin.HandlerFunc {
 }
 
 func ResponseCodeMetric(endpoint string, code int) {
-	responseCounterTotal.WithLabelValues(endpoint, strconv.Itoa(code)).Inc()
+	ensureHTTPMetrics()
+	responseCounterTotal.WithLabelValues(append([]string{endpoint, strconv.Itoa(code)}, make([]string, len(extraLabels))...)...).Inc()
+}
+
+func LogEntryMetric(level string, channel string) {
+	logEntriesTotal.WithLabelValues(level, channel).Inc()
 }
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// GatewayPusher periodically pushes the metrics registry to a Pushgateway
+type GatewayPusher struct {
+	pusher *push.Pusher
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// PushToGateway pushes all metrics to the Pushgateway at url under job every interval,
+// interval <= 0 only pushes on Close. Call Close before the process exits to push the final values
+func PushToGateway(url string, job string, interval time.Duration) *GatewayPusher {
+	p := &GatewayPusher{
+		pusher: push.New(url, job).Gatherer(Gatherer()),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run(interval)
+	return p
+}
+
+func (p *GatewayPusher) run(interval time.Duration) {
+	defer close(p.done)
+	if interval <= 0 {
+		<-p.stop
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.push()
+		}
+	}
+}
+
+func (p *GatewayPusher) push() error {
+	err := p.pusher.Push()
+	p.mu.Lock()
+	p.lastErr = err
+	p.mu.Unlock()
+	return err
+}
+
+// Err returns the error of the latest push, nil if it succeeded
+func (p *GatewayPusher) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastErr
+}
+
+// Close stops the periodic push and pushes the final values once
+func (p *GatewayPusher) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+	return p.push()
+}
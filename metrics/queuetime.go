@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queueTimeHeaders are set by load balancers with the time the request was received
+var queueTimeHeaders = []string{"X-Request-Start", "X-Queue-Start"}
+
+// requestQueueTime returns how long the request waited before now, false when no header is set
+// or the timestamp is in the future because of clock skew
+func requestQueueTime(header http.Header, now time.Time) (time.Duration, bool) {
+	for _, name := range queueTimeHeaders {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		start, ok := parseRequestStart(value)
+		if !ok {
+			continue
+		}
+		queued := now.Sub(start)
+		return queued, queued >= 0
+	}
+	return 0, false
+}
+
+// parseRequestStart 解析 "t=1700000000.123" 或不带前缀的 Unix 时间戳，按数量级识别秒、毫秒、微秒与纳秒
+func parseRequestStart(value string) (time.Time, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "t=")
+	ts, err := strconv.ParseFloat(value, 64)
+	if err != nil || ts <= 0 {
+		return time.Time{}, false
+	}
+	switch {
+	case ts > 1e17:
+		return time.Unix(0, int64(ts)), true
+	case ts > 1e14:
+		return time.UnixMicro(int64(ts)), true
+	case ts > 1e11:
+		return time.UnixMilli(int64(ts)), true
+	default:
+		return time.Unix(0, int64(ts*1e9)), true
+	}
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// httpObservation is one request recorded by PrometheusGinMiddleware
+type httpObservation struct {
+	endpoint     string
+	status       int
+	elapsed      time.Duration
+	requestSize  int64
+	responseSize int
+	// apdexZone 为空表示该 endpoint 不计算 apdex
+	apdexZone string
+	// responseCode 为空表示未设置 ResponseCodeMetricKey
+	responseCode string
+	// extra ExtraLabels 的标签值
+	extra []string
+	// queueTime 请求在负载均衡排队的时间，hasQueueTime 为 false 表示请求头中没有时间戳
+	queueTime    time.Duration
+	hasQueueTime bool
+}
+
+// httpRecorder is the backend of the HTTP server metrics, selected by Init
+type httpRecorder interface {
+	inFlight(ctx context.Context, method string, endpoint string, extra []string, delta int64)
+	record(ctx context.Context, obs httpObservation)
+}
+
+// recorder 默认记录到 Prometheus
+var recorder httpRecorder = promRecorder{}
+
+type promRecorder struct{}
+
+func (promRecorder) inFlight(_ context.Context, method string, endpoint string, extra []string, delta int64) {
+	httpRequestsInFlight.WithLabelValues(labelValues(extra, endpoint)...).Add(float64(delta))
+	httpRequestsInFlightByMethod.WithLabelValues(method).Add(float64(delta))
+}
+
+func (promRecorder) record(ctx context.Context, obs httpObservation) {
+	httpRequestsTotal.WithLabelValues(labelValues(obs.extra, obs.endpoint, strconv.Itoa(obs.status))...).Inc()
+	httpRequestsByClass.WithLabelValues(labelValues(obs.extra, obs.endpoint, statusClass(obs.status))...).Inc()
+	observeWithTrace(ctx, httpRequestSize.WithLabelValues(labelValues(obs.extra, obs.endpoint)...), float64(obs.requestSize))
+	observeWithTrace(ctx, httpRequestDuration.WithLabelValues(labelValues(obs.extra, obs.endpoint)...), float64(obs.elapsed.Milliseconds()))
+	observeWithTrace(ctx, httpResponseSize.WithLabelValues(labelValues(obs.extra, obs.endpoint)...), float64(obs.responseSize))
+	if obs.hasQueueTime {
+		httpRequestQueueTime.Observe(float64(obs.queueTime.Milliseconds()))
+	}
+	if obs.apdexZone != "" {
+		httpApdexTotal.WithLabelValues(labelValues(obs.extra, obs.endpoint, obs.apdexZone)...).Inc()
+	}
+	if obs.responseCode != "" {
+		responseCounterTotal.WithLabelValues(labelValues(obs.extra, obs.endpoint, obs.responseCode)...).Inc()
+	}
+}
+
+// OtherMethod replaces request methods outside the RFC 9110 set in labels
+const OtherMethod = "OTHER"
+
+// normalizeMethod 非标准方法统一记为 OTHER，避免客户端用任意方法制造无限的 series
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return OtherMethod
+	}
+}
+
+// statusClass 返回状态码的分类，例如 2xx、5xx
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// labelValues 在固定标签值之后追加 ExtraLabels 的值
+func labelValues(extra []string, values ...string) []string {
+	return append(values, extra...)
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPoolStatser is implemented by redis.UniversalClient
+type RedisPoolStatser interface {
+	PoolStats() *redis.PoolStats
+}
+
+// redisPoolCollector exports go-redis connection pool stats on each scrape
+type redisPoolCollector struct {
+	client RedisPoolStatser
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	waits      *prometheus.Desc
+	waitTime   *prometheus.Desc
+	staleConns *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+}
+
+func newRedisPoolCollector(name string, client RedisPoolStatser) *redisPoolCollector {
+	labels := prometheus.Labels{"name": name}
+	desc := func(metric string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("", "redis_pool", metric), help, nil, labels)
+	}
+	return &redisPoolCollector{
+		client:     client,
+		hits:       desc("hits_total", "Total number of times a free connection was found in the pool"),
+		misses:     desc("misses_total", "Total number of times a free connection was not found in the pool"),
+		timeouts:   desc("timeouts_total", "Total number of times waiting for a connection timed out"),
+		waits:      desc("waits_total", "Total number of times waiting for a connection"),
+		waitTime:   desc("wait_duration_seconds_total", "Total time spent waiting for a connection (seconds)"),
+		staleConns: desc("stale_connections_total", "Total number of stale connections removed from the pool"),
+		totalConns: desc("connections", "Number of connections in the pool"),
+		idleConns:  desc("idle_connections", "Number of idle connections in the pool"),
+	}
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.hits, c.misses, c.timeouts, c.waits, c.waitTime, c.staleConns, c.totalConns, c.idleConns} {
+		ch <- d
+	}
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	if stats == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitTime, prometheus.CounterValue, float64(stats.WaitDurationNs)/1e9)
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+}
+
+// RegisterRedisPoolCollector exports the pool stats of client labeled with name and returns a func
+// that unregisters it. Registering the same name twice keeps the first client
+func RegisterRedisPoolCollector(name string, client RedisPoolStatser) func() {
+	c := newRedisPoolCollector(name, client)
+	if err := register(c); err != nil {
+		return func() {}
+	}
+	return func() { unregister(c) }
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// registry tracks every collector of this package so they can be moved to another registry
+var registry = struct {
+	mu         sync.Mutex
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+	collectors []prometheus.Collector
+}{
+	registerer: prometheus.DefaultRegisterer,
+	gatherer:   prometheus.DefaultGatherer,
+}
+
+// UseRegistry moves all collectors of this package, including those registered later,
+// from the current registry to reg and returns a handler serving reg.
+// Use it to avoid name collisions with other libraries or to isolate metrics in tests
+func UseRegistry(reg *prometheus.Registry) http.Handler {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, c := range registry.collectors {
+		registry.registerer.Unregister(c)
+		reg.MustRegister(c)
+	}
+	registry.registerer = reg
+	registry.gatherer = reg
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg, EnableOpenMetrics: true})
+}
+
+// Registerer returns the registry the collectors of this package are registered to
+func Registerer() prometheus.Registerer {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return registry.registerer
+}
+
+// Gatherer returns the registry the collectors of this package are gathered from
+func Gatherer() prometheus.Gatherer {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return registry.gatherer
+}
+
+// mustRegister 注册到当前 registry 并记录，注册失败时 panic
+func mustRegister[T prometheus.Collector](c T) T {
+	if err := register(c); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// register 注册到当前 registry 并记录，返回 Register 的错误
+func register(c prometheus.Collector) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if err := registry.registerer.Register(c); err != nil {
+		return err
+	}
+	registry.collectors = append(registry.collectors, c)
+	return nil
+}
+
+// registerIgnoreExisting 注册 collector，已注册时忽略
+func registerIgnoreExisting(c prometheus.Collector) {
+	if err := register(c); err != nil {
+		var existing prometheus.AlreadyRegisteredError
+		if !errors.As(err, &existing) {
+			panic(err)
+		}
+	}
+}
+
+// unregister 从当前 registry 移除
+func unregister(c prometheus.Collector) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.registerer.Unregister(c)
+	registry.collectors = slices.DeleteFunc(registry.collectors, func(existing prometheus.Collector) bool {
+		return existing == c
+	})
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Version and Commit are reported by the build_info gauge, set them at build time with
+// -ldflags "-X github.com/TomWu-Alchemi/project-framework/metrics.Version=v1.2.3 -X github.com/TomWu-Alchemi/project-framework/metrics.Commit=abc123"
+var (
+	Version = "unknown"
+	Commit  = "unknown"
+)
+
+var runtimeMetricsOnce sync.Once
+
+// EnableRuntimeMetrics registers the Go runtime collector with GC, memory and scheduler metrics,
+// the process collector and the build_info gauge. Calling it more than once has no effect
+func EnableRuntimeMetrics() {
+	runtimeMetricsOnce.Do(func() {
+		// 替换默认注册的 go collector，增加 runtime/metrics 中的 GC、内存与调度指标
+		Registerer().Unregister(collectors.NewGoCollector())
+		registerIgnoreExisting(collectors.NewGoCollector(
+			collectors.WithGoCollectorRuntimeMetrics(
+				collectors.MetricsGC,
+				collectors.MetricsMemory,
+				collectors.MetricsScheduler,
+			),
+		))
+		registerIgnoreExisting(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+		buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "build_info",
+			Help: "Build information of the running binary, always 1",
+			ConstLabels: prometheus.Labels{
+				"version":    Version,
+				"commit":     Commit,
+				"go_version": runtime.Version(),
+			},
+		})
+		buildInfo.Set(1)
+		registerIgnoreExisting(buildInfo)
+	})
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SLO metrics, the error ratio over a window is
+// rate(slo_errors_total[w]) / rate(slo_requests_total[w]) and the burn rate divides it by (1 - slo_objective)
+var (
+	sloRequestsTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slo_requests_total",
+			Help: "Total number of requests counted by the SLO",
+		},
+		[]string{"slo"},
+	))
+
+	sloErrorsTotal = mustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slo_errors_total",
+			Help: "Total number of requests violating the SLO by failing or exceeding the latency threshold",
+		},
+		[]string{"slo"},
+	))
+
+	sloObjective = mustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "slo_objective",
+			Help: "Target ratio of good requests of the SLO, e.g. 0.999",
+		},
+		[]string{"slo"},
+	))
+)
+
+// SLO counts a request as an error when IsError returns true or its latency exceeds LatencyThreshold
+type SLO struct {
+	Name string
+	// Endpoint is matched against the endpoint label of PrometheusGinMiddleware, e.g. "GET_/api/users/:id",
+	// leave it empty for SLOs recorded by RecordSLO only
+	Endpoint string
+	// Objective is the target ratio of good requests, e.g. 0.999
+	Objective float64
+	// LatencyThreshold 0 means latency is not part of the SLO
+	LatencyThreshold time.Duration
+	// IsError defaults to status >= 500
+	IsError func(status int) bool
+}
+
+var slos = struct {
+	mu         sync.RWMutex
+	byName     map[string]SLO
+	byEndpoint map[string][]SLO
+}{
+	byName:     make(map[string]SLO),
+	byEndpoint: make(map[string][]SLO),
+}
+
+// RegisterSLO registers slo and exports its objective, registering the same name again replaces it
+func RegisterSLO(slo SLO) {
+	if slo.IsError == nil {
+		slo.IsError = func(status int) bool { return status >= http.StatusInternalServerError }
+	}
+	slos.mu.Lock()
+	defer slos.mu.Unlock()
+	if old, ok := slos.byName[slo.Name]; ok && old.Endpoint != "" {
+		// 复制后删除，recordEndpointSLOs 可能仍在读取原切片
+		slos.byEndpoint[old.Endpoint] = slices.DeleteFunc(slices.Clone(slos.byEndpoint[old.Endpoint]), func(s SLO) bool {
+			return s.Name == slo.Name
+		})
+	}
+	slos.byName[slo.Name] = slo
+	if slo.Endpoint != "" {
+		slos.byEndpoint[slo.Endpoint] = append(slos.byEndpoint[slo.Endpoint], slo)
+	}
+	sloObjective.WithLabelValues(slo.Name).Set(slo.Objective)
+}
+
+// RecordSLO records a request for the SLO registered as name, e.g. for RPC handlers.
+// Unregistered names are ignored
+func RecordSLO(name string, status int, latency time.Duration) {
+	slos.mu.RLock()
+	slo, ok := slos.byName[name]
+	slos.mu.RUnlock()
+	if ok {
+		slo.record(status, latency)
+	}
+}
+
+// recordEndpointSLOs 记录 endpoint 上注册的所有 SLO
+func recordEndpointSLOs(endpoint string, status int, latency time.Duration) {
+	slos.mu.RLock()
+	endpointSLOs := slos.byEndpoint[endpoint]
+	slos.mu.RUnlock()
+	for _, slo := range endpointSLOs {
+		slo.record(status, latency)
+	}
+}
+
+func (s SLO) record(status int, latency time.Duration) {
+	sloRequestsTotal.WithLabelValues(s.Name).Inc()
+	if s.IsError(status) || (s.LatencyThreshold > 0 && latency > s.LatencyThreshold) {
+		sloErrorsTotal.WithLabelValues(s.Name).Inc()
+	}
+}
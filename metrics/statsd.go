@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// StatsDConfig mirrors the HTTP server metrics to a StatsD or DogStatsD agent over UDP
+type StatsDConfig struct {
+	// Addr of the agent, e.g. "127.0.0.1:8125"
+	Addr string
+	// Prefix is prepended to metric names, e.g. "myapp." gives "myapp.http.requests"
+	Prefix string
+	// Tags are attached to every metric in DogStatsD format, e.g. env and app
+	Tags map[string]string
+}
+
+var tagReplacer = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+
+// statsdRecorder 每次观测发送一个 UDP 包，多个指标以换行分隔
+type statsdRecorder struct {
+	conn        net.Conn
+	prefix      string
+	tags        string
+	extraLabels []string
+
+	// active 按 endpoint 记录正在处理的请求数，以 gauge 发送
+	active sync.Map
+}
+
+func newStatsDRecorder(conf StatsDConfig, extraLabels []string) (*statsdRecorder, error) {
+	conn, err := net.Dial("udp", conf.Addr)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(conf.Tags))
+	for k, v := range conf.Tags {
+		tags = append(tags, tagReplacer.Replace(k)+":"+tagReplacer.Replace(v))
+	}
+	return &statsdRecorder{conn: conn, prefix: conf.Prefix, tags: strings.Join(tags, ","), extraLabels: extraLabels}, nil
+}
+
+// line 格式为 name:value|type|#tag:value,...
+func (r *statsdRecorder) line(b *strings.Builder, name string, value string, metricType string, tags ...string) {
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+	b.WriteString(r.prefix)
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(metricType)
+	if len(tags) == 0 && r.tags == "" {
+		return
+	}
+	b.WriteString("|#")
+	for i, tag := range tags {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(tagReplacer.Replace(tag))
+	}
+	if r.tags != "" {
+		if len(tags) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(r.tags)
+	}
+}
+
+// send 丢弃发送错误，UDP 不保证送达
+func (r *statsdRecorder) send(b *strings.Builder) {
+	_, _ = r.conn.Write([]byte(b.String()))
+}
+
+// endpointTags endpoint 与 ExtraLabels 的 tag
+func (r *statsdRecorder) endpointTags(endpoint string, extra []string) []string {
+	tags := make([]string, 0, len(extra)+2)
+	tags = append(tags, "endpoint:"+endpoint)
+	for i, v := range extra {
+		tags = append(tags, r.extraLabels[i]+":"+v)
+	}
+	return tags
+}
+
+func (r *statsdRecorder) inFlight(_ context.Context, _ string, endpoint string, extra []string, delta int64) {
+	// 按 endpoint 与 ExtraLabels 的值分别计数
+	key := strings.Join(append([]string{endpoint}, extra...), "\x00")
+	v, _ := r.active.LoadOrStore(key, &atomic.Int64{})
+	n := v.(*atomic.Int64).Add(delta)
+	var b strings.Builder
+	r.line(&b, "http.requests_in_flight", strconv.FormatInt(n, 10), "g", r.endpointTags(endpoint, extra)...)
+	r.send(&b)
+}
+
+func (r *statsdRecorder) record(_ context.Context, obs httpObservation) {
+	tags := r.endpointTags(obs.endpoint, obs.extra)
+	var b strings.Builder
+	r.line(&b, "http.requests", "1", "c", append(tags, "status:"+strconv.Itoa(obs.status), "class:"+statusClass(obs.status))...)
+	r.line(&b, "http.request_duration", strconv.FormatInt(obs.elapsed.Milliseconds(), 10), "ms", tags...)
+	r.line(&b, "http.request_size", strconv.FormatInt(obs.requestSize, 10), "h", tags...)
+	r.line(&b, "http.response_size", strconv.Itoa(obs.responseSize), "h", tags...)
+	if obs.hasQueueTime {
+		r.line(&b, "http.request_queue_time", strconv.FormatInt(obs.queueTime.Milliseconds(), 10), "ms")
+	}
+	if obs.apdexZone != "" {
+		r.line(&b, "http.apdex", "1", "c", append(tags, "zone:"+obs.apdexZone)...)
+	}
+	if obs.responseCode != "" {
+		r.line(&b, "response.total", "1", "c", append(tags, "code:"+obs.responseCode)...)
+	}
+	r.send(&b)
+}
+
+func (r *statsdRecorder) Close() error {
+	return r.conn.Close()
+}
+
+// multiRecorder 同时记录到多个后端
+type multiRecorder []httpRecorder
+
+func (m multiRecorder) inFlight(ctx context.Context, method string, endpoint string, extra []string, delta int64) {
+	for _, r := range m {
+		r.inFlight(ctx, method, endpoint, extra, delta)
+	}
+}
+
+func (m multiRecorder) record(ctx context.Context, obs httpObservation) {
+	for _, r := range m {
+		r.record(ctx, obs)
+	}
+}
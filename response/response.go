@@ -1,6 +1,8 @@
 package response
 
 import (
+	"net/http"
+
 	"github.com/TomWu-Alchemi/project-framework/metrics"
 	"github.com/gin-gonic/gin"
 )
@@ -53,6 +55,14 @@ func Failed2(endpoint string, code int, msg string, ext []Pair) CommonResponse {
 	}
 }
 
+// RecoveryHandler 返回 panic 恢复处理函数，以统一的失败结构响应并记录业务响应码，
+// 配合 logger.CustomRecoveryWithZap 使用
+func RecoveryHandler(code int, msg string) gin.RecoveryFunc {
+	return func(c *gin.Context, err any) {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, Failed(c, code, msg, nil))
+	}
+}
+
 func successResponseStatus(msg string, ext []Pair) ResponseStatus {
 	return ResponseStatus{
 		Code:      200,
@@ -0,0 +1,131 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+	"github.com/bytedance/sonic"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	errors2 "github.com/pkg/errors"
+	"time"
+)
+
+// DefaultCallTimeout ctx 没有截止时间且未设置 WithCallTimeout 时的超时
+const DefaultCallTimeout = 5 * time.Second
+
+// ErrNoDeadline WithCallTimeout 设置为 <= 0 而 ctx 没有截止时间
+var ErrNoDeadline = errors.New("rpc call has no deadline")
+
+// ErrServiceError 服务端通过 micro.Request.Error 返回的错误，具体的错误码与描述见 *ServiceError
+var ErrServiceError = errors.New("rpc service error")
+
+// ServiceError 服务端返回的错误响应，errors.Is(err, ErrServiceError) 为 true
+type ServiceError struct {
+	Subject     string
+	Code        string
+	Description string
+	// Data 错误响应体
+	Data []byte
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("rpc service error: subject:(%s) code:%s description:%s", e.Subject, e.Code, e.Description)
+}
+
+func (e *ServiceError) Is(target error) bool {
+	return target == ErrServiceError
+}
+
+type callOptions struct {
+	timeout    time.Duration
+	hasTimeout bool
+	headers    nats.Header
+}
+
+type CallOption func(*callOptions)
+
+// WithCallTimeout 本次调用的超时，ctx 的截止时间更早时以 ctx 为准。
+// <= 0 表示只使用 ctx 的截止时间，ctx 没有截止时间时返回 ErrNoDeadline
+func WithCallTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+		o.hasTimeout = true
+	}
+}
+
+// WithHeader 设置请求头
+func WithHeader(k string, v string) CallOption {
+	return func(o *callOptions) {
+		if o.headers == nil {
+			o.headers = nats.Header{}
+		}
+		o.headers.Set(k, v)
+	}
+}
+
+// Call 用 sonic 编码 req 发送到 subject 并把响应解码到 TResp，透传请求 ID、剩余超时与 trace context。
+// 服务端返回错误时返回 *ServiceError，响应体为空时返回 TResp 的零值
+func Call[TReq any, TResp any](ctx context.Context, nc *nats.Conn, subject string, req TReq, opts ...CallOption) (TResp, error) {
+	var resp TResp
+	o := callOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	_, hasDeadline := ctx.Deadline()
+	if !o.hasTimeout && !hasDeadline {
+		o.timeout = DefaultCallTimeout
+	}
+	if o.timeout <= 0 && !hasDeadline {
+		return resp, errors2.WithStack(ErrNoDeadline)
+	}
+	data, err := sonic.Marshal(req)
+	if err != nil {
+		return resp, errors2.WithStack(err)
+	}
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	for k, v := range o.headers {
+		msg.Header[k] = v
+	}
+	if id := logger.RequestIDFromContext(ctx); id != "" {
+		msg.Header.Set(logger.RequestIDHeader, id)
+	}
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	setTimeoutHeader(ctx, msg.Header)
+
+	span := startProducerSpan(ctx, msg)
+	start := time.Now()
+	reply, err := nc.RequestMsgWithContext(ctx, msg)
+	if err == nil {
+		err = replyError(subject, reply)
+	}
+	metrics.Dependency(subject).ObserveCall(time.Since(start), err)
+	endSpan(span, err)
+	if err != nil {
+		return resp, errors2.WithStack(err)
+	}
+	if len(reply.Data) == 0 {
+		return resp, nil
+	}
+	if err = sonic.Unmarshal(reply.Data, &resp); err != nil {
+		return resp, errors2.WithStack(err)
+	}
+	return resp, nil
+}
+
+// replyError 把 micro 的错误响应头转换为 *ServiceError
+func replyError(subject string, reply *nats.Msg) error {
+	code := reply.Header.Get(micro.ErrorCodeHeader)
+	description := reply.Header.Get(micro.ErrorHeader)
+	if code == "" && description == "" {
+		return nil
+	}
+	return &ServiceError{Subject: subject, Code: code, Description: description, Data: reply.Data}
+}
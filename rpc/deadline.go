@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"context"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"strconv"
+	"time"
+)
+
+// TimeoutHeader 调用方剩余的超时时间（毫秒）。传递剩余时间而不是截止时间点，避免两端时钟不一致
+const TimeoutHeader = "X-Request-Timeout"
+
+// setTimeoutHeader 把 ctx 的截止时间写入请求头
+func setTimeoutHeader(ctx context.Context, header nats.Header) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	header.Set(TimeoutHeader, strconv.FormatInt(max(time.Until(deadline).Milliseconds(), 0), 10))
+}
+
+// Deadline 按请求头中调用方的剩余超时设置 ctx 的截止时间，调用方放弃后 handler 中的下游调用随之取消
+func Deadline(fn HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, rawReq micro.Request) {
+		if value := rawReq.Headers().Get(TimeoutHeader); value != "" {
+			if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms >= 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+				defer cancel()
+			}
+		}
+		fn(ctx, rawReq)
+	}
+}
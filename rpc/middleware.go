@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"context"
+	"github.com/TomWu-Alchemi/project-framework/logger"
+	"github.com/nats-io/nats.go/micro"
+	"go.uber.org/zap"
+	"runtime/debug"
+	"time"
+)
+
+// HandlerFunc NATS 接口的处理函数，可直接传给 micro.ContextHandler。
+// 定义为别名，使 metrics.NatsRPCMiddleware 等不依赖 rpc 包的函数也能作为 Middleware
+type HandlerFunc = func(context.Context, micro.Request)
+
+// Middleware 包装 HandlerFunc，用于访问日志、指标、鉴权、参数校验与 panic 恢复等
+type Middleware func(HandlerFunc) HandlerFunc
+
+// Chain 按顺序用 mws 包装 handler，第一个 middleware 在最外层，例如
+//
+//	rpc.Chain(handler, rpc.NatsRpcAccessLog, metrics.NatsRPCMiddleware)
+func Chain(handler HandlerFunc, mws ...Middleware) HandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
+
+// Recovery 恢复 handler 的 panic 并记录到 recovery 日志，不记录访问日志
+func Recovery(fn HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, rawReq micro.Request) {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(ctx, rawReq, r)
+			}
+		}()
+		fn(ctx, rawReq)
+	}
+}
+
+// Use 添加服务级 middleware，在 Register 注册的接口上先于接口自己的 middleware 执行
+func (s *NatsService) Use(mws ...Middleware) {
+	s.middlewares = append(s.middlewares, mws...)
+}
+
+func logPanic(ctx context.Context, rawReq micro.Request, r any) {
+	logger.GetRecoveryLog().Error("[Recovery from rpc panic]",
+		zap.Time("time", time.Now()),
+		zap.Any("error", r),
+		zap.String("path", rawReq.Subject()),
+		zap.ByteString("data", rawReq.Data()),
+		zap.String("header", headersToString(rawReq.Headers())),
+		zap.String("stack", string(debug.Stack())),
+		logger.RequestIDField(ctx))
+}
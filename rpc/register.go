@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"context"
+	"github.com/TomWu-Alchemi/project-framework/metrics"
+	"github.com/nats-io/nats.go/micro"
+	errors2 "github.com/pkg/errors"
+	"strings"
+)
+
+// DefaultMiddlewares Register 默认在最外层添加的 middleware：访问日志与 panic 恢复、链路追踪、指标、调用方超时
+var DefaultMiddlewares = []Middleware{NatsRpcAccessLog, Tracing, metrics.NatsRPCMiddleware, Deadline}
+
+type registerOptions struct {
+	subject            string
+	queueGroup         string
+	queueGroupDisabled bool
+	metadata           map[string]string
+	middlewares        []Middleware
+	withoutDefaults    bool
+}
+
+type RegisterOption func(*registerOptions)
+
+// WithSubject 用 subject 代替 name 作为接口的 subject，group 不为空时仍带有 group 前缀，可用 Subject 拼接
+func WithSubject(subject string) RegisterOption {
+	return func(o *registerOptions) {
+		o.subject = subject
+	}
+}
+
+// WithQueueGroup 覆盖服务的 queue group，默认为 micro.DefaultQueueGroup
+func WithQueueGroup(queueGroup string) RegisterOption {
+	return func(o *registerOptions) {
+		o.queueGroup = queueGroup
+	}
+}
+
+// WithoutQueueGroup 不使用 queue group，每个实例都处理请求
+func WithoutQueueGroup() RegisterOption {
+	return func(o *registerOptions) {
+		o.queueGroupDisabled = true
+	}
+}
+
+// WithMetadata 接口的元数据，出现在 micro 的 INFO 响应中
+func WithMetadata(metadata map[string]string) RegisterOption {
+	return func(o *registerOptions) {
+		o.metadata = metadata
+	}
+}
+
+// WithMiddleware 接口自己的 middleware，在服务级 middleware 之后执行
+func WithMiddleware(mws ...Middleware) RegisterOption {
+	return func(o *registerOptions) {
+		o.middlewares = append(o.middlewares, mws...)
+	}
+}
+
+// WithoutDefaultMiddlewares 不添加 DefaultMiddlewares
+func WithoutDefaultMiddlewares() RegisterOption {
+	return func(o *registerOptions) {
+		o.withoutDefaults = true
+	}
+}
+
+// Subject 用 "." 拼接 subject，忽略空的部分
+func Subject(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, ".")
+}
+
+// Register 在 group 下注册名为 name 的接口，subject 默认为 group.name，group 为空时为 name。
+// handler 依次经过 DefaultMiddlewares、Use 添加的服务级 middleware 与 WithMiddleware 包装
+func (s *NatsService) Register(group string, name string, handler HandlerFunc, opts ...RegisterOption) error {
+	o := registerOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var mws []Middleware
+	if !o.withoutDefaults {
+		mws = append(mws, DefaultMiddlewares...)
+	}
+	mws = append(mws, s.middlewares...)
+	mws = append(mws, o.middlewares...)
+
+	var endpointOpts []micro.EndpointOpt
+	if o.subject != "" {
+		endpointOpts = append(endpointOpts, micro.WithEndpointSubject(o.subject))
+	}
+	if o.queueGroupDisabled {
+		endpointOpts = append(endpointOpts, micro.WithEndpointQueueGroupDisabled())
+	} else if o.queueGroup != "" {
+		endpointOpts = append(endpointOpts, micro.WithEndpointQueueGroup(o.queueGroup))
+	}
+	if o.metadata != nil {
+		endpointOpts = append(endpointOpts, micro.WithEndpointMetadata(o.metadata))
+	}
+
+	h := micro.ContextHandler(context.Background(), Chain(handler, mws...))
+	var err error
+	if group == "" {
+		err = s.srv.AddEndpoint(name, h, endpointOpts...)
+	} else {
+		err = s.srv.AddGroup(group).AddEndpoint(name, h, endpointOpts...)
+	}
+	return errors2.WithStack(err)
+}
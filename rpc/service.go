@@ -9,7 +9,6 @@ import (
 	errors2 "github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"runtime/debug"
 	"strings"
 	"time"
 )
@@ -17,6 +16,8 @@ import (
 type NatsService struct {
 	nc  *nats.Conn
 	srv micro.Service
+	// middlewares 服务级 middleware
+	middlewares []Middleware
 }
 
 type ServiceConfig struct {
@@ -26,15 +27,23 @@ type ServiceConfig struct {
 	AppName      string        `json:"app_name"`
 	Version      string        `json:"version"`
 	DrainTimeout time.Duration `json:"drain_timeout"`
+	// MaxReconnects 最大重连次数，0 使用 nats 默认值 60，-1 表示不限次数
+	MaxReconnects int `json:"max_reconnects"`
+	// ReconnectWait 重连间隔，0 使用 nats 默认值 2s
+	ReconnectWait time.Duration `json:"reconnect_wait"`
+
+	// OnDisconnect 断开连接时回调，未设置时记录错误日志
+	OnDisconnect func(nc *nats.Conn, err error) `json:"-"`
+	// OnReconnect 重连成功时回调，未设置时记录重连次数与上次错误
+	OnReconnect func(nc *nats.Conn) `json:"-"`
+	// OnClosed 连接关闭且不再重连时回调，未设置时记录日志
+	OnClosed func(nc *nats.Conn) `json:"-"`
+	// OnError 异步错误（例如慢消费者）回调，未设置时记录错误日志
+	OnError func(nc *nats.Conn, sub *nats.Subscription, err error) `json:"-"`
 }
 
 func NewNatsService(config ServiceConfig) (*NatsService, func(), error) {
-	nc, err := nats.Connect(config.Url,
-		nats.UserInfo(config.Username, config.Password),
-		nats.DisconnectErrHandler(func(conn *nats.Conn, err error) {
-			logger.Error(fmt.Sprintf("nats rpc disconnect error occur, err(%v）", err))
-		}),
-		nats.DrainTimeout(config.DrainTimeout))
+	nc, err := nats.Connect(config.Url, connectOptions(config)...)
 	if err != nil {
 		return nil, func() {}, errors2.WithStack(err)
 	}
@@ -67,17 +76,15 @@ func NewNatsService(config ServiceConfig) (*NatsService, func(), error) {
 	return natsSrv, cleanup, nil
 }
 
-func NatsRpcAccessLog(fn func(context.Context, micro.Request)) func(context.Context, micro.Request) {
+// NatsRpcAccessLog 记录访问日志并恢复 panic，可作为 Chain 的 Middleware
+func NatsRpcAccessLog(fn HandlerFunc) HandlerFunc {
 	return func(ctx context.Context, rawReq micro.Request) {
+		if id := rawReq.Headers().Get(logger.RequestIDHeader); id != "" {
+			ctx = logger.WithRequestID(ctx, id)
+		}
 		defer func() {
 			if r := recover(); r != nil {
-				logger.GetRecoveryLog().Error("[Recovery from rpc panic]",
-					zap.Time("time", time.Now()),
-					zap.Any("error", r),
-					zap.String("path", rawReq.Subject()),
-					zap.ByteString("data", rawReq.Data()),
-					zap.String("header", headersToString(rawReq.Headers())),
-					zap.String("stack", string(debug.Stack())))
+				logPanic(ctx, rawReq, r)
 			}
 		}()
 
@@ -90,11 +97,61 @@ func NatsRpcAccessLog(fn func(context.Context, micro.Request)) func(context.Cont
 			zap.ByteString("data", rawReq.Data()),
 			zap.String("header", headersToString(rawReq.Headers())),
 			zap.Int64("latency_ms", time.Since(start).Milliseconds()),
+			logger.RequestIDField(ctx),
 		}
 		logger.GetAccessLog().Info("nats-rpc", logFields...)
 	}
 }
 
+// connectOptions 按配置生成连接选项，未设置的回调使用默认的日志记录
+func connectOptions(config ServiceConfig) []nats.Option {
+	opts := []nats.Option{
+		nats.UserInfo(config.Username, config.Password),
+		nats.DrainTimeout(config.DrainTimeout),
+	}
+	if config.MaxReconnects != 0 {
+		opts = append(opts, nats.MaxReconnects(config.MaxReconnects))
+	}
+	if config.ReconnectWait > 0 {
+		opts = append(opts, nats.ReconnectWait(config.ReconnectWait))
+	}
+
+	onDisconnect := config.OnDisconnect
+	if onDisconnect == nil {
+		onDisconnect = func(conn *nats.Conn, err error) {
+			logger.Error(fmt.Sprintf("nats rpc disconnect error occur, err(%v）", err))
+		}
+	}
+	onReconnect := config.OnReconnect
+	if onReconnect == nil {
+		onReconnect = func(conn *nats.Conn) {
+			logger.Info(fmt.Sprintf("nats rpc reconnected to %s, reconnects(%d) last err(%v)", conn.ConnectedUrlRedacted(), conn.Stats().Reconnects, conn.LastError()))
+		}
+	}
+	onClosed := config.OnClosed
+	if onClosed == nil {
+		onClosed = func(conn *nats.Conn) {
+			logger.Warn(fmt.Sprintf("nats rpc connection closed, reconnects(%d) last err(%v)", conn.Stats().Reconnects, conn.LastError()))
+		}
+	}
+	onError := config.OnError
+	if onError == nil {
+		onError = func(conn *nats.Conn, sub *nats.Subscription, err error) {
+			subject := ""
+			if sub != nil {
+				subject = sub.Subject
+			}
+			logger.Error(fmt.Sprintf("nats rpc async error occur, subject(%s) err(%v)", subject, err))
+		}
+	}
+	return append(opts,
+		nats.DisconnectErrHandler(onDisconnect),
+		nats.ReconnectHandler(onReconnect),
+		nats.ClosedHandler(onClosed),
+		nats.ErrorHandler(onError),
+	)
+}
+
 func (s *NatsService) GetSrv() micro.Service {
 	return s.srv
 }
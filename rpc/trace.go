@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"context"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/TomWu-Alchemi/project-framework/rpc"
+
+// headerCarrier 按原样读写 NATS 请求头。NATS 请求头区分大小写，不能用 propagation.HeaderCarrier 规范化键名
+type headerCarrier nats.Header
+
+func (c headerCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// startProducerSpan 为一次调用创建 producer span，并按全局 TextMapPropagator 注入 traceparent 等请求头。
+// 未设置全局 TracerProvider 与 Propagator 时均为 no-op
+func startProducerSpan(ctx context.Context, msg *nats.Msg) trace.Span {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "send "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.operation.type", "send"),
+			attribute.String("messaging.destination.name", msg.Subject),
+		),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(msg.Header))
+	return span
+}
+
+// endSpan 记录错误并结束 span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceRequest 记录 handler 返回的错误码
+type traceRequest struct {
+	micro.Request
+	span trace.Span
+}
+
+func (r *traceRequest) Error(code, description string, data []byte, opts ...micro.RespondOpt) error {
+	r.span.SetAttributes(attribute.String("rpc.error_code", code))
+	r.span.SetStatus(codes.Error, description)
+	return r.Request.Error(code, description, data, opts...)
+}
+
+// Tracing 从请求头提取调用方的 trace context 并为 handler 创建 consumer span，
+// handler 调用 Error 或 panic 时 span 标记为错误，panic 继续交给外层 middleware 恢复
+func Tracing(fn HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, rawReq micro.Request) {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier(rawReq.Headers()))
+		ctx, span := otel.Tracer(tracerName).Start(ctx, "process "+rawReq.Subject(),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				attribute.String("messaging.system", "nats"),
+				attribute.String("messaging.operation.type", "process"),
+				attribute.String("messaging.destination.name", rawReq.Subject()),
+			),
+		)
+		defer func() {
+			if r := recover(); r != nil {
+				span.SetStatus(codes.Error, "panic")
+				span.End()
+				panic(r)
+			}
+			span.End()
+		}()
+		fn(ctx, &traceRequest{Request: rawReq, span: span})
+	}
+}
@@ -17,3 +17,10 @@ func CalcAndCompareHmac(h func() hash.Hash, secretKey string, msg string, compar
 	}
 	return hmac.Equal(sum, decode)
 }
+
+// CalcHmac 计算 msg 的 HMAC 并以十六进制返回，与 CalcAndCompareHmac 配对使用
+func CalcHmac(h func() hash.Hash, secretKey string, msg string) string {
+	w := hmac.New(h, []byte(secretKey))
+	_, _ = io.WriteString(w, msg)
+	return hex.EncodeToString(w.Sum(nil))
+}